@@ -1,16 +1,19 @@
 module moritz/go-fractals
 
-go 1.17
+go 1.18
+
+require (
+	github.com/dustin/go-humanize v1.0.0
+	github.com/gosuri/uilive v0.0.4
+	github.com/schollz/progressbar/v3 v3.8.6
+)
 
 require (
-	github.com/dustin/go-humanize v1.0.0 // indirect
-	github.com/gosuri/uilive v0.0.4 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/mattn/go-runewidth v0.0.13 // indirect
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/schollz/progressbar v1.0.0 // indirect
-	github.com/schollz/progressbar/v3 v3.8.6 // indirect
 	golang.org/x/crypto v0.0.0-20220131195533-30dcbda58838 // indirect
 	golang.org/x/sys v0.0.0-20220128215802-99c3d69c2c27 // indirect
 	golang.org/x/term v0.0.0-20210927222741-03fcf44c2211 // indirect
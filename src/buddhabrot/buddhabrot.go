@@ -9,11 +9,15 @@ import (
 	"image/color"
 	"image/png"
 	"io"
+	"math"
 	"math/big"
+	mathrand "math/rand"
 	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/complexdd"
 	"moritz/go-fractals/src/core"
+	"moritz/go-fractals/src/fill"
 	"moritz/go-fractals/src/optimizations"
-	"moritz/go-fractals/src/utils"
+	"moritz/go-fractals/src/utils/metrics"
 	"os"
 	"strconv"
 	"sync"
@@ -24,22 +28,34 @@ import (
 	"github.com/schollz/progressbar/v3"
 )
 
-const MAX_UINT16 = ^uint16(0)
-
 var (
-	prec       int
-	maxIt      int
-	cycleSize  int
-	nCycles    int
-	density    *SafeDensity
-	xMax       float64 = 2
-	xMin       float64 = -2
-	yMax       float64 = 1
-	yMin       float64 = -1
-	maxThreads int
-	endless    bool
-	warmStart  bool
-	gridSize   int = 500
+	prec        int
+	maxIt       int
+	cycleSize   int
+	nCycles     int
+	density     *SafeDensity
+	xMax        float64 = 2
+	xMin        float64 = -2
+	yMax        float64 = 1
+	yMin        float64 = -1
+	maxThreads  int
+	endless     bool
+	warmStart   bool
+	gridSize    int = 500
+	gridSizeY   int
+	gridBounds  string
+	gridFill    string
+	gridCache   string
+	borderMap   string
+	quadDepth   int
+	dilation    int
+	components  bool
+	backend     string
+	sampling    string
+	adaptEvery  int
+	gridImage   string
+	gridScale   int
+	metricsAddr string
 )
 
 // const width int = 7205 * 2
@@ -47,29 +63,30 @@ const width int = 500 * 2
 const height int = width / 2
 
 var wg sync.WaitGroup
-var mu sync.Mutex
 var quitInitiated bool
-var nFoundPoints *utils.SafeCounter = utils.MakeSafeCounter()
+var nFoundPoints *metrics.Counter = metrics.NewCounter()
 var nOldPoints int64 = 0
-var nCyclesRun *utils.SafeCounter = utils.MakeSafeCounter()
-var lastMax uint16 = 0
+var nCyclesRun *metrics.Counter = metrics.NewCounter()
+var lastMax *metrics.Gauge = metrics.NewGauge()
+
+// pointsRate is the number of new points per second over the last 10 seconds
+var pointsRate *metrics.Rate = metrics.NewRate(nFoundPoints, 10*time.Second)
 var start time.Time
 
-var grid *optimizations.Grid
+var ctx *complexbig.Context
+var pipe cycleRunner
 
 var (
 	xDelta float64 = xMax - xMin
 	yDelta float64 = yMax - yMin
 )
 
+// pixel is a point of the density, n is the number of hits it adds. n is the
+// weight of the sample, so it is fractional with importance sampling.
 type pixel struct {
 	x uint16
 	y uint16
-}
-
-type SafeDensity struct {
-	sync.Mutex
-	d *[width][width * 2]uint16
+	n float32
 }
 
 type writers struct {
@@ -82,26 +99,48 @@ type writers struct {
 }
 
 func init() {
-	flag.IntVar(&prec, "prec", 100, "precision of big.float numbers")
+	flag.IntVar(&prec, "prec", 100, "precision of big.float numbers, 0 derives it from the pixel size")
 	flag.IntVar(&maxIt, "maxIt", 100, "maximum number of iteratations")
 	flag.IntVar(&cycleSize, "cycleSize", 100, "number of points per cycle")
 	flag.IntVar(&nCycles, "nCycles", 100, "number of cycles")
-	flag.IntVar(&maxThreads, "maxThreads", 4, "maximum number of threads")
+	flag.IntVar(&maxThreads, "maxThreads", 4, "maximum number of threads, every thread adds a density buffer of 8 MB")
 	flag.BoolVar(&endless, "endless", false, "endless mode, nCycles is ignored")
 	flag.BoolVar(&warmStart, "warmStart", false, "warm start, load density and max from files")
-	flag.IntVar(&gridSize, "gridSize", 500, "size of the grid that is used for border detection")
-
-	flag.Parse()
+	flag.IntVar(&gridSize, "gridSize", 500, "number of points along the real axis of the grid that is used for border detection")
+	flag.IntVar(&gridSizeY, "gridSizeY", 0, "number of points along the imaginary axis of the grid, 0 uses gridSize")
+	flag.StringVar(&gridBounds, "gridBounds", optimizations.DefaultBounds.String(), "region of the grid as xMin,xMax,yMin,yMax, points are only sampled within it")
+	flag.StringVar(&gridFill, "gridFill", "brute", "strategy to compute the grid: brute, mariani or boundary, the latter two miss minibrots and filaments that are islands at grid resolution")
+	flag.StringVar(&gridCache, "gridCache", "", "directory in which grids are cached between runs, empty disables the cache")
+	flag.StringVar(&gridImage, "gridImage", "", "file to which an image of the grid is saved, in set black, outside white and border red")
+	flag.IntVar(&gridScale, "gridScale", 1, "size of the grid cells in pixels in the grid image")
+	flag.StringVar(&borderMap, "borderMap", "grid", "border detection: grid or quadtree, the quadtree covers the grid bounds")
+	flag.IntVar(&quadDepth, "quadDepth", 10, "maximum depth of the quadtree, it resolves the border with 2^quadDepth cells per axis")
+	flag.IntVar(&dilation, "dilation", 0, "number of quadtree cells around the border in which points are also sampled")
+	flag.BoolVar(&components, "knownComponents", true, "skip points in the table of known components besides the main cardioid and the period 2 bulb")
+	flag.StringVar(&sampling, "sampling", "border", "sampling of points: border rejects points that are not at the border, importance samples them from the grid cells weighted by their contribution")
+	flag.IntVar(&adaptEvery, "adaptEvery", 100, "number of cycles after which the importance weights are updated from the observed contributions, 0 keeps them uniform")
+	flag.StringVar(&metricsAddr, "metricsAddr", "", "address like :9090 on which the stats are served in the Prometheus text format at /metrics, empty disables it")
+	flag.StringVar(&backend, "backend", "", "number type of the iteration: float64, double-double, quad-double or big.Float, empty selects it by precision")
 }
 
 func main() {
+	// flags are parsed here and not in init, so test binaries can define
+	// their own
+	flag.Parse()
 
 	fmt.Println("Creating image with resolution", width, "x", height)
+	initContext()
 	initDensityArray()
 
-	start = time.Now()
-	grid = optimizations.NewGrid(gridSize, maxIt, maxThreads)
-	fmt.Printf("Grid created in %s\n", time.Since(start))
+	initPipeline()
+
+	if metricsAddr != "" {
+		listener, err := startMetricsServer(metricsAddr)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("Serving metrics at http://%s/metrics\n", listener.Addr())
+	}
 
 	go renderPeriodically(2)
 
@@ -115,32 +154,82 @@ func main() {
 
 }
 
+func initContext() {
+	if prec <= 0 {
+		ctx = complexbig.ContextForPixelSize(big.NewFloat(xDelta / float64(width)))
+		prec = int(ctx.Prec)
+	} else {
+		ctx = complexbig.NewContext(uint(prec))
+	}
+	fmt.Println("Using", ctx.Prec, "bits of precision")
+}
+
+// initPipeline creates the pipeline with the number type of the backend flag
+// or the fastest one that is precise enough
+func initPipeline() {
+	gridStrategy, err := fill.ParseStrategy(gridFill)
+	if err != nil {
+		panic(err)
+	}
+	if gridStrategy != fill.BruteForce {
+		fmt.Println("Warning: the", gridStrategy, "fill misses minibrots and filaments that are islands at grid resolution, their border is not sampled")
+	}
+	bounds, err := optimizations.ParseBounds(gridBounds)
+	if err != nil {
+		panic(err)
+	}
+	nY := gridSizeY
+	if nY <= 0 {
+		nY = gridSize
+	}
+
+	selected := core.BackendForPrecision(ctx.Prec)
+	if backend != "" {
+		selected, err = core.ParseBackend(backend)
+		if err != nil {
+			panic(err)
+		}
+	}
+	fmt.Println("Using the", selected, "backend")
+
+	switch selected {
+	case core.Float64:
+		pipe = newPipeline[complex128](core.Float64Arithmetic{}, bounds, nY, gridStrategy)
+	case core.DoubleDouble:
+		pipe = newPipeline[complexdd.ComplexDD](core.DDArithmetic{}, bounds, nY, gridStrategy)
+	case core.QuadDouble:
+		pipe = newPipeline[complexdd.ComplexQD](core.QDArithmetic{}, bounds, nY, gridStrategy)
+	default:
+		pipe = newPipeline[*complexbig.ComplexBig](core.BigArithmetic{Ctx: ctx}, bounds, nY, gridStrategy)
+	}
+}
+
 func initDensityArray() {
 	if !warmStart {
-		density = &SafeDensity{d: &[width][width * 2]uint16{}}
+		density = newSafeDensity(&[width][width * 2]float64{}, maxThreads+1)
 		return
 	}
 
 	loadedDensity, err := loadDensity("buddhabrot.png", "max.txt")
 	if err != nil {
-		density = &SafeDensity{d: &[width][width * 2]uint16{}}
+		density = newSafeDensity(&[width][width * 2]float64{}, maxThreads+1)
 		return
 	}
 
-	density = &SafeDensity{d: loadedDensity}
+	density = newSafeDensity(loadedDensity, maxThreads+1)
 
-	sumPoints := int64(0)
-	max := uint16(0)
+	sumPoints := 0.0
+	max := 0.0
 	for i := 0; i < width; i++ {
 		for j := 0; j < width*2; j++ {
-			sumPoints += int64(density.d[i][j])
+			sumPoints += density.d[i][j]
 			if density.d[i][j] > max {
 				max = density.d[i][j]
 			}
 		}
 	}
 	nOldPoints = int64(sumPoints)
-	lastMax = max
+	lastMax.Set(float64(max))
 	fmt.Println("Loaded", humanize.Comma(int64(sumPoints)), "points")
 	fmt.Println("Max:", max)
 
@@ -151,9 +240,9 @@ func runNCycles() {
 	bar := progressbar.Default(int64(nCycles))
 	for i := 0; i < nCycles; i++ {
 		guard <- struct{}{}
+		wg.Add(1)
 		go func() {
-			wg.Add(1)
-			runCycle()
+			pipe.runCycle()
 			bar.Add(1)
 			<-guard
 			wg.Done()
@@ -190,7 +279,7 @@ func runEndless() {
 	for {
 		guard <- struct{}{}
 		go func() {
-			runCycle()
+			pipe.runCycle()
 			<-guard
 		}()
 	}
@@ -207,16 +296,12 @@ func printStatsPeriodically(every int, writers *writers) {
 }
 
 func printStats(writers *writers) {
-	secondsSinceStart := int64(time.Since(start).Seconds())
-	if secondsSinceStart == 0 {
-		secondsSinceStart = 1
-	}
-	pointsPerSecond := nFoundPoints.Value() / secondsSinceStart
+	pointsRate.Sample(time.Now())
 	printStat(writers.cyclesWriter, "Cycles started", nCyclesRun.Value())
 	printStat(writers.totalWriter, "Total points", (nFoundPoints.Value() + nOldPoints))
 	printStat(writers.totalNewWriter, "New points", (nFoundPoints.Value()))
-	printStat(writers.maxWriter, "Maximum number of trajectory hits", int64(lastMax))
-	printStat(writers.speedWriter, "Avg. points / second", (pointsPerSecond))
+	printStat(writers.maxWriter, "Maximum number of trajectory hits", int64(lastMax.Value()))
+	printStat(writers.speedWriter, "Points / second over the last 10s", int64(pointsRate.PerSecond()))
 
 	fmt.Fprintf(writers.timeWriter, "Time elapsed %s \n", time.Since(start).String())
 }
@@ -245,143 +330,262 @@ func renderPeriodically(every int) {
 	}
 }
 
-func runCycle() {
-	numbers := generateNumbers()
-	numbers = filterNumbers(numbers)
-	trajectories := iteratePoints(numbers)
+// cycleRunner runs cycles of the pipeline independent of its number type
+type cycleRunner interface {
+	runCycle()
+}
 
-	mirroredTrajectories := mirrorPoints(trajectories)
-	trajectories = append(trajectories, mirroredTrajectories...)
+// pipeline generates, filters and iterates points with the number type T
+type pipeline[T any] struct {
+	arith  core.Arithmetic[T]
+	border optimizations.BorderMap
+	// importance is nil if points are sampled uniformly and rejected if
+	// they are not at the border
+	importance *optimizations.ImportanceMap
+}
 
-	pixels := translatePoints(trajectories)
+// sample is a point c that was sampled from cell of the importance map,
+// weight corrects for the sampling distribution
+type sample struct {
+	c      *complexbig.ComplexBig
+	cell   int
+	weight float64
+}
 
-	nFoundPoints.Add(int64(len(pixels)))
-	nCyclesRun.Add(1)
+func newPipeline[T any](arith core.Arithmetic[T], bounds optimizations.Bounds, nY int, gridStrategy fill.Strategy) *pipeline[T] {
+	p := &pipeline[T]{
+		arith:  arith,
+		border: newBorderMap(arith, bounds, nY, gridStrategy),
+	}
 
-	incrementDensity(pixels)
+	switch sampling {
+	case "border":
+	case "importance":
+		grid, ok := p.border.(*optimizations.Grid[T])
+		if !ok {
+			panic("importance sampling needs the grid border map")
+		}
+		importance, err := optimizations.ImportanceFromGrid(grid)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("Sampling from %v cells at the border\n", importance.Cells())
+		p.importance = importance
+	default:
+		panic("unknown sampling " + sampling)
+	}
+	return p
 }
 
-func generateNumbers() []*complexbig.ComplexBig {
+// quadMinDepth is the depth up to which the quadtree is subdivided
+// everywhere, so that small components are not hidden in coarse cells
+const quadMinDepth = 6
 
-	numbers := make([]*complexbig.ComplexBig, cycleSize)
-	for j := 0; j < cycleSize; j++ {
-		r := generateRandom(3, complexbig.Two)
-		i := generateRandom(2, complexbig.One)
-		numbers[j] = &complexbig.ComplexBig{R: r, I: i}
+func newBorderMap[T any](arith core.Arithmetic[T], bounds optimizations.Bounds, nY int, gridStrategy fill.Strategy) optimizations.BorderMap {
+	start = time.Now()
+	switch borderMap {
+	case "quadtree":
+		tree := optimizations.NewQuadtree(arith, bounds, quadMinDepth, quadDepth, maxIt, maxThreads)
+		tree.SetDilation(dilation)
+		fmt.Printf("Quadtree with %v cells created in %s\n", tree.Leaves(), time.Since(start))
+		return tree
+	case "grid":
+	default:
+		panic("unknown border map " + borderMap)
+	}
+
+	var grid *optimizations.Grid[T]
+	if gridCache == "" {
+		grid = optimizations.NewGrid(arith, bounds, gridSize, nY, maxIt, maxThreads, gridStrategy)
+		fmt.Printf("Grid of %v x %v points created in %s\n", gridSize, nY, time.Since(start))
+	} else {
+		var cached bool
+		var err error
+		grid, cached, err = optimizations.CachedGrid(gridCache, arith, bounds, gridSize, nY, maxIt, maxThreads, gridStrategy)
+		if err != nil {
+			fmt.Println("Could not cache the grid:", err)
+		}
+		if cached {
+			fmt.Printf("Grid of %v x %v points loaded from %s in %s\n", gridSize, nY, gridCache, time.Since(start))
+		} else {
+			fmt.Printf("Grid of %v x %v points created in %s\n", gridSize, nY, time.Since(start))
+		}
 	}
-	return numbers
+
+	fmt.Println("Grid:", grid.Stats())
+	if gridImage != "" {
+		if err := optimizations.SaveGridImage(gridImage, grid, gridScale); err != nil {
+			panic(err)
+		}
+		fmt.Println("Grid image saved to", gridImage)
+	}
+	return grid
 }
 
-func filterNumbers(numbers []*complexbig.ComplexBig) []*complexbig.ComplexBig {
-	filtered := make([]*complexbig.ComplexBig, 0, cycleSize)
-	for _, z := range numbers {
-		if !optimizations.IsAtBorder(z, grid) {
+// importanceMix is the fraction of the importance weights that is spread
+// evenly over the cells, so that no cell of the border is starved
+const importanceMix = 0.2
+
+func (p *pipeline[T]) runCycle() {
+	samples := p.generateNumbers()
+	samples = p.filterNumbers(samples)
+
+	pixels := make([]*pixel, 0)
+	for _, s := range samples {
+		trajectory, _ := core.IterateWith(p.arith, p.arith.FromBig(s.c), maxIt)
+		if trajectory == nil {
+			nRejectedInSet.Inc()
+			if p.importance != nil {
+				p.importance.Observe(s.cell, 0)
+			}
 			continue
 		}
 
-		if optimizations.IsInMainCardiod(z) {
-			continue
+		trajectory = append(trajectory, p.mirrorPoints(trajectory)...)
+		trajectoryPixels := p.translatePoints(trajectory, float32(s.weight))
+		// the contribution is observed independent of the weight
+		if p.importance != nil {
+			p.importance.Observe(s.cell, len(trajectoryPixels))
+		}
+		if len(trajectoryPixels) > 0 && trajectoryPixels[0].n > 0 {
+			pixels = append(pixels, trajectoryPixels...)
 		}
+	}
 
-		filtered = append(filtered, z)
+	found := 0.0
+	for _, pixel := range pixels {
+		found += float64(pixel.n)
+	}
+	nFoundPoints.Add(int64(math.Round(found)))
+	nCyclesRun.Add(1)
+
+	incrementDensity(pixels)
+
+	if p.importance != nil && adaptEvery > 0 && nCyclesRun.Value()%int64(adaptEvery) == 0 {
+		p.importance.Adapt(importanceMix)
 	}
-	return filtered
 }
 
-func iteratePoints(numbers []*complexbig.ComplexBig) []*complexbig.ComplexBig {
-	trajectories := make([]*complexbig.ComplexBig, 0, len(numbers))
+// generateNumbers samples points uniformly within the bounds of the border
+// map or from the cells of the importance map
+func (p *pipeline[T]) generateNumbers() []sample {
+	samples := make([]sample, cycleSize)
+	nSamples.Add(int64(cycleSize))
+	for j := 0; j < cycleSize; j++ {
+		s := sample{cell: -1, weight: 1}
+		bounds := p.border.Bounds()
+		if p.importance != nil {
+			s.cell, s.weight = p.importance.Pick(mathrand.Float64())
+			bounds = p.importance.Cell(s.cell)
+		}
+
+		r := generateRandom(bounds.XMin, bounds.XMax)
+		i := generateRandom(bounds.YMin, bounds.YMax)
+		s.c = &complexbig.ComplexBig{R: r, I: i}
+		samples[j] = s
+	}
+	return samples
+}
 
-	for j := 0; j < len(numbers); j++ {
-		trajectoryPoints, _ := core.Iterate(numbers[j], maxIt)
+func (p *pipeline[T]) filterNumbers(samples []sample) []sample {
+	var knownComponents []optimizations.Disc
+	if components {
+		knownComponents = optimizations.KnownComponents
+	}
+
+	filtered := make([]sample, 0, cycleSize)
+	for _, s := range samples {
+		// the filters only need float64 precision, so c is converted once
+		x, _ := s.c.R.Float64()
+		y, _ := s.c.I.Float64()
+
+		// importance samples are drawn from border cells only
+		if p.importance == nil && !p.border.IsAtBorderFloat64(x, y) {
+			nRejectedBorder.Inc()
+			continue
+		}
 
-		if trajectoryPoints == nil {
+		// interior points do not escape and contribute nothing
+		if optimizations.IsKnownInteriorFloat64(x, y, knownComponents) {
+			nRejectedInterior.Inc()
+			if p.importance != nil {
+				p.importance.Observe(s.cell, 0)
+			}
 			continue
 		}
-		trajectories = append(trajectories, trajectoryPoints...)
+
+		filtered = append(filtered, s)
 	}
-	return trajectories
+	return filtered
 }
 
-func mirrorPoints(points []*complexbig.ComplexBig) []*complexbig.ComplexBig {
-	mirroredPoints := make([]*complexbig.ComplexBig, 0)
+func (p *pipeline[T]) mirrorPoints(points []T) []T {
+	mirroredPoints := make([]T, 0)
 	for _, z := range points {
-		mirrored := z.MirrorImaginary()
+		mirrored := p.arith.MirrorImaginary(z)
 		mirroredPoints = append(mirroredPoints, mirrored)
 	}
 	return mirroredPoints
 }
 
-func generateRandom(size int, offset *big.Float) *big.Float {
+// generateRandom returns a random number in [min, max) with prec random bits
+func generateRandom(min, max float64) *big.Float {
 
-	max := new(big.Int)
-	max.Exp(big.NewInt(2), big.NewInt(int64(prec)), nil).Sub(max, big.NewInt(1))
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(prec))
 
-	n, err := rand.Int(rand.Reader, max)
+	n, err := rand.Int(rand.Reader, limit)
 	if err != nil {
 		//error handling
 	}
 
-	r := new(big.Float).SetInt(n)
-
-	r.SetMantExp(r, r.MantExp(r)-(prec-size+1))
-	r.Sub(r, offset)
+	// r = min + n / 2^prec * (max - min)
+	r := ctx.NewFloat(0).SetInt(n)
+	r.SetMantExp(r, -prec)
+	r.Mul(r, ctx.NewFloat(max-min))
+	r.Add(r, ctx.NewFloat(min))
 	return r
 }
 
-func translatePoints(points []*complexbig.ComplexBig) []*pixel {
+// translatePoints converts the points to pixels that each add n hits
+func (p *pipeline[T]) translatePoints(points []T, n float32) []*pixel {
 	pixels := make([]*pixel, 0, len(points))
 	for _, c := range points {
-		pixel := translatePoint(c)
+		pixel := translatePoint(p.arith.Float64(c))
 		if pixel == nil {
 			continue
 		}
+		pixel.n = n
 		pixels = append(pixels, pixel)
 	}
 	return pixels
 }
 
-func translatePoint(point *complexbig.ComplexBig) *pixel {
-	r, _ := point.R.Float64()
+func translatePoint(r, i float64) *pixel {
 	if r > xMax || r < xMin {
 		return nil
 	}
-	i, _ := point.I.Float64()
 	if i > yMax || i < yMin {
 		return nil
 	}
 
 	return &pixel{
 		x: uint16(((r - xMin) / xDelta) * float64(width)),
-		y: uint16(((i - yMin) / yDelta) * float64(height))}
+		y: uint16(((i - yMin) / yDelta) * float64(height)),
+		n: 1}
 }
 
 func incrementDensity(pixels []*pixel) {
-	mu.Lock()
-	for _, pixel := range pixels {
-		// if density.d[pixel.x][pixel.y] == MAX_UINT16 {
-		// 	panic("overflow")
-		// }
-		density.d[pixel.x][pixel.y]++
-	}
-	mu.Unlock()
+	density.add(pixels)
 }
 
-func copyDensity() *[width][width * 2]uint16 {
-	mu.Lock()
-	defer mu.Unlock()
-	d := &[width][width * 2]uint16{}
-	for i := 0; i < width; i++ {
-		for j := 0; j < width*2; j++ {
-			d[i][j] = density.d[i][j]
-		}
-	}
-	return d
+func copyDensity() *[width][width * 2]float64 {
+	return density.copy()
 }
 
-func render(density *[width][width * 2]uint16) {
+func render(density *[width][width * 2]float64) {
 
 	max := findMax(density)
-	lastMax = max
+	lastMax.Set(float64(max))
 
 	saveMax(max)
 
@@ -393,18 +597,18 @@ func render(density *[width][width * 2]uint16) {
 	saveImage(img)
 }
 
-func drawImage(img *image.RGBA, density *[width][width * 2]uint16, max uint16) {
+func drawImage(img *image.RGBA, density *[width][width * 2]float64, max float64) {
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
-			sat := uint8(float32(density[x][y]) / float32(max) * float32(255))
+			sat := uint8(density[x][y] / max * 255)
 			c := color.RGBA{sat, sat, sat, 255}
 			img.Set(x, y, c)
 		}
 	}
 }
 
-func findMax(density *[width][width * 2]uint16) uint16 {
-	max := uint16(0)
+func findMax(density *[width][width * 2]float64) float64 {
+	max := 0.0
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			if density[x][y] > max {
@@ -415,13 +619,13 @@ func findMax(density *[width][width * 2]uint16) uint16 {
 	return max
 }
 
-func saveMax(max uint16) {
+func saveMax(max float64) {
 	f, err := os.Create("max.txt")
 	if err != nil {
 		panic(err)
 	}
 	defer f.Close()
-	f.WriteString(strconv.Itoa(int(max)))
+	f.WriteString(strconv.FormatFloat(max, 'g', -1, 64))
 }
 
 func saveImage(img *image.RGBA) {
@@ -433,7 +637,7 @@ func saveImage(img *image.RGBA) {
 	png.Encode(file, img)
 }
 
-func loadDensity(imagePath string, maxPath string) (*[width][width * 2]uint16, error) {
+func loadDensity(imagePath string, maxPath string) (*[width][width * 2]float64, error) {
 	imgFile, err := os.Open(imagePath)
 	if err != nil {
 		return nil, err
@@ -455,18 +659,18 @@ func loadDensity(imagePath string, maxPath string) (*[width][width * 2]uint16, e
 	// read single number from file
 	scanner := bufio.NewScanner(maxFile)
 	scanner.Scan()
-	max, err := strconv.Atoi(scanner.Text())
+	max, err := strconv.ParseFloat(scanner.Text(), 64)
 	if err != nil {
 		return nil, err
 	}
 
-	density := &[width][width * 2]uint16{}
+	density := &[width][width * 2]float64{}
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			c := img.At(x, y)
 			r, _, _, _ := c.RGBA()
 			r = r / 256
-			density[x][y] = uint16(int(r) * max / 255)
+			density[x][y] = float64(r) * max / 255
 		}
 	}
 	return density, nil
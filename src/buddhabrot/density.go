@@ -0,0 +1,95 @@
+package main
+
+import "sync"
+
+// SafeDensity accumulates the hits of the workers. Every worker adds its
+// pixels to one of several shards without waiting for the others, the shards
+// are merged into the total density when it is copied. So workers never wait
+// for each other or for rendering. Every shard is a full density buffer of
+// width*width*2 float32, 8 MB at the default width, so the memory grows with
+// the number of shards, which is maxThreads+1. The shards only hold the hits
+// between two merges, the total is float64, so a pixel keeps counting single
+// hits after float32 would have stopped at 2^24.
+type SafeDensity struct {
+	// mu guards d, the merged density
+	mu sync.Mutex
+	d  *[width][width * 2]float64
+
+	shards []*densityShard
+}
+
+// densityShard holds the hits since the last merge
+type densityShard struct {
+	sync.Mutex
+	d     *[width][width * 2]float32
+	dirty bool
+}
+
+// newSafeDensity creates a density that starts with d and has nShards shards.
+// With one shard more than concurrent workers a worker finds a free shard
+// while another one is merged.
+func newSafeDensity(d *[width][width * 2]float64, nShards int) *SafeDensity {
+	if nShards < 1 {
+		nShards = 1
+	}
+	density := &SafeDensity{d: d, shards: make([]*densityShard, nShards)}
+	for k := range density.shards {
+		density.shards[k] = &densityShard{d: &[width][width * 2]float32{}}
+	}
+	return density
+}
+
+// add adds the hits of the pixels to the first free shard, it only blocks if
+// all shards are in use
+func (density *SafeDensity) add(pixels []*pixel) {
+	if len(pixels) == 0 {
+		return
+	}
+	shard := density.lockShard()
+	for _, pixel := range pixels {
+		shard.d[pixel.x][pixel.y] += pixel.n
+	}
+	shard.dirty = true
+	shard.Unlock()
+}
+
+func (density *SafeDensity) lockShard() *densityShard {
+	for _, shard := range density.shards {
+		if shard.TryLock() {
+			return shard
+		}
+	}
+	shard := density.shards[0]
+	shard.Lock()
+	return shard
+}
+
+// merge adds the hits of all shards to the total density and clears them
+func (density *SafeDensity) merge() {
+	density.mu.Lock()
+	defer density.mu.Unlock()
+	for _, shard := range density.shards {
+		shard.Lock()
+		if shard.dirty {
+			for i := range shard.d {
+				for j, hits := range shard.d[i] {
+					if hits != 0 {
+						density.d[i][j] += float64(hits)
+						shard.d[i][j] = 0
+					}
+				}
+			}
+			shard.dirty = false
+		}
+		shard.Unlock()
+	}
+}
+
+// copy merges the shards and returns a copy of the total density
+func (density *SafeDensity) copy() *[width][width * 2]float64 {
+	density.merge()
+	density.mu.Lock()
+	defer density.mu.Unlock()
+	d := *density.d
+	return &d
+}
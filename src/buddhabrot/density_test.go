@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mutexDensity is the previous design, all workers share one buffer that is
+// guarded by a single mutex
+type mutexDensity struct {
+	mu sync.Mutex
+	d  *[width][width * 2]float64
+}
+
+func (density *mutexDensity) add(pixels []*pixel) {
+	density.mu.Lock()
+	for _, pixel := range pixels {
+		density.d[pixel.x][pixel.y] += float64(pixel.n)
+	}
+	density.mu.Unlock()
+}
+
+func (density *mutexDensity) copy() *[width][width * 2]float64 {
+	density.mu.Lock()
+	defer density.mu.Unlock()
+	d := *density.d
+	return &d
+}
+
+func randomPixels(rnd *rand.Rand, n int) []*pixel {
+	pixels := make([]*pixel, n)
+	for k := range pixels {
+		pixels[k] = &pixel{x: uint16(rnd.Intn(width)), y: uint16(rnd.Intn(height)), n: 1}
+	}
+	return pixels
+}
+
+func TestSafeDensity(t *testing.T) {
+	initial := &[width][width * 2]float64{}
+	initial[3][4] = 7
+	density := newSafeDensity(initial, 3)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				density.add([]*pixel{{x: 3, y: 4, n: 1}, {x: uint16(w), y: 0, n: 2}})
+				if k%10 == 0 {
+					density.copy()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	d := density.copy()
+	if d[3][4] != 807 {
+		t.Fatalf("expected 807 hits, got %v", d[3][4])
+	}
+	for w := 0; w < 8; w++ {
+		if d[w][0] != 200 {
+			t.Fatalf("expected 200 hits at %v, 0, got %v", w, d[w][0])
+		}
+	}
+}
+
+func TestSafeDensityKeepsWeights(t *testing.T) {
+	initial := &[width][width * 2]float64{}
+	initial[7][8] = 1 << 24
+	density := newSafeDensity(initial, 1)
+	for k := 0; k < 100000; k++ {
+		density.add([]*pixel{{x: 1, y: 2, n: 0.25}, {x: 5, y: 6, n: 2.5}})
+	}
+
+	density.add([]*pixel{{x: 7, y: 8, n: 1}})
+
+	d := density.copy()
+	// float32 cannot add 1 to 2^24
+	if d[7][8] != 1<<24+1 {
+		t.Fatalf("expected %v hits, got %v", 1<<24+1, d[7][8])
+	}
+	// the weights are neither rounded nor wrapped around at 65536
+	if d[1][2] != 25000 || d[5][6] != 250000 {
+		t.Fatalf("expected 25000 and 250000 hits, got %v and %v", d[1][2], d[5][6])
+	}
+}
+
+type densityAccumulator interface {
+	add(pixels []*pixel)
+	copy() *[width][width * 2]float64
+}
+
+// benchmarkDensity adds batches of pixels like the cycles of the pipeline
+// while the density is copied periodically like for rendering
+func benchmarkDensity(b *testing.B, density densityAccumulator) {
+	batches := make([][]*pixel, 64)
+	rnd := rand.New(rand.NewSource(1))
+	for k := range batches {
+		batches[k] = randomPixels(rnd, 1000)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(20 * time.Millisecond):
+				density.copy()
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		k := 0
+		for pb.Next() {
+			density.add(batches[k%len(batches)])
+			k++
+		}
+	})
+	b.StopTimer()
+	close(done)
+}
+
+func BenchmarkDensity(b *testing.B) {
+	b.Run("mutex", func(b *testing.B) {
+		benchmarkDensity(b, &mutexDensity{d: &[width][width * 2]float64{}})
+	})
+	b.Run("sharded", func(b *testing.B) {
+		// one shard per parallel worker of RunParallel and one spare
+		benchmarkDensity(b, newSafeDensity(&[width][width * 2]float64{}, runtime.GOMAXPROCS(0)+1))
+	})
+}
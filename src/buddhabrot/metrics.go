@@ -0,0 +1,51 @@
+package main
+
+import (
+	"moritz/go-fractals/src/utils/metrics"
+	"net"
+	"net/http"
+	"time"
+)
+
+// counters of the samples, a sample is rejected by at most one filter
+var (
+	nSamples          *metrics.Counter = metrics.NewCounter()
+	nRejectedBorder   *metrics.Counter = metrics.NewCounter()
+	nRejectedInterior *metrics.Counter = metrics.NewCounter()
+	nRejectedInSet    *metrics.Counter = metrics.NewCounter()
+)
+
+// newMetricsRegistry registers the stats of printStats and the counters of
+// the filters
+func newMetricsRegistry() *metrics.Registry {
+	r := metrics.NewRegistry()
+	r.Counter("buddhabrot_cycles_total", "Number of cycles that were completed.", nCyclesRun)
+	r.Counter("buddhabrot_new_points_total", "Number of points added to the density in this run.", nFoundPoints)
+	r.GaugeFunc("buddhabrot_points", "Number of points in the density including the ones of a warm start.", func() float64 {
+		return float64(nFoundPoints.Value() + nOldPoints)
+	})
+	r.Gauge("buddhabrot_max_hits", "Maximum number of trajectory hits of a pixel at the last render.", lastMax)
+	r.GaugeFunc("buddhabrot_points_per_second", "New points per second over the last 10 seconds.", func() float64 {
+		pointsRate.Sample(time.Now())
+		return pointsRate.PerSecond()
+	})
+	r.Counter("buddhabrot_samples_total", "Number of sampled points c.", nSamples)
+	r.Counter("buddhabrot_samples_rejected_total", "Number of sampled points that were rejected by a filter.", nRejectedBorder, "filter", "border")
+	r.Counter("buddhabrot_samples_rejected_total", "", nRejectedInterior, "filter", "known_interior")
+	r.Counter("buddhabrot_samples_rejected_total", "", nRejectedInSet, "filter", "in_set")
+	return r
+}
+
+// startMetricsServer serves the metrics at /metrics on addr in the
+// background and returns the listener, whose address is resolved if addr
+// has port 0
+func startMetricsServer(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", newMetricsRegistry())
+	go http.Serve(listener, mux)
+	return listener, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsServer(t *testing.T) {
+	listener, err := startMetricsServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	nCyclesRun.Add(3)
+	nRejectedBorder.Add(5)
+	lastMax.Set(17)
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"# TYPE buddhabrot_cycles_total counter\nbuddhabrot_cycles_total 3\n",
+		"\nbuddhabrot_max_hits 17\n",
+		"\nbuddhabrot_points_per_second ",
+		"\nbuddhabrot_samples_rejected_total{filter=\"border\"} 5\n",
+		"\nbuddhabrot_samples_rejected_total{filter=\"known_interior\"} 0\n",
+		"\nbuddhabrot_samples_rejected_total{filter=\"in_set\"} 0\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("expected %q in\n%s", line, body)
+		}
+	}
+}
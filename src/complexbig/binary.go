@@ -0,0 +1,46 @@
+package complexbig
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler. Both parts are stored
+// with the gob encoding of big.Float, which keeps their precision, rounding
+// mode and accuracy.
+func (z *ComplexBig) MarshalBinary() ([]byte, error) {
+	r, err := z.R.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+	i, err := z.I.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+
+	// the length of the real part separates the parts
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(r)+len(i))
+	buf = buf[:binary.PutUvarint(buf, uint64(len(r)))]
+	buf = append(buf, r...)
+	return append(buf, i...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (z *ComplexBig) UnmarshalBinary(data []byte) error {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || uint64(len(data)-size) < n {
+		return errors.New("complexbig: invalid binary encoding")
+	}
+	data = data[size:]
+
+	r, i := new(big.Float), new(big.Float)
+	if err := r.GobDecode(data[:n]); err != nil {
+		return err
+	}
+	if err := i.GobDecode(data[n:]); err != nil {
+		return err
+	}
+	z.R, z.I = r, i
+	return nil
+}
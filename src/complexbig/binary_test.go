@@ -0,0 +1,54 @@
+package complexbig
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/big"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	ctx := &Context{Prec: 300, Mode: big.ToZero}
+	numbers := []*ComplexBig{
+		ctx.Div(ctx.New(1, -2), ctx.New(3, 7)),
+		NewContext(53).New(-0.5, 0),
+		{R: new(big.Float), I: new(big.Float).SetInf(true)},
+	}
+
+	for _, z := range numbers {
+		data, err := z.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := new(ComplexBig)
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equals(z) || decoded.R.Prec() != z.R.Prec() || decoded.I.Mode() != z.I.Mode() {
+			t.Fatalf("expected %v, got %v", z.Text('g', -1), decoded.Text('g', -1))
+		}
+	}
+
+	if err := new(ComplexBig).UnmarshalBinary([]byte{200}); err == nil {
+		t.Fatalf("expected an error for invalid data")
+	}
+}
+
+func TestGob(t *testing.T) {
+	ctx := NewContext(200)
+	trajectory := []*ComplexBig{ctx.New(0.25, -1), ctx.Div(ctx.New(1, 0), ctx.New(3, 0))}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(trajectory); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*ComplexBig
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	for i := range trajectory {
+		if !decoded[i].Equals(trajectory[i]) || decoded[i].R.Prec() != 200 {
+			t.Fatalf("expected %v, got %v", trajectory[i], decoded[i])
+		}
+	}
+}
@@ -10,6 +10,8 @@ var Two *big.Float = big.NewFloat(2)
 type ComplexBig struct {
 	R *big.Float
 	I *big.Float
+
+	scratch *scratch
 }
 
 // Mul a*b=z
@@ -24,10 +26,16 @@ func Mul(a, b *ComplexBig) (z *ComplexBig) {
 	return &ComplexBig{R: newR, I: newI}
 }
 
-// Add a+b=z
+// Add a+b=z, the result is stored in a without allocations
 func (a *ComplexBig) Add(b *ComplexBig) (z *ComplexBig) {
-	a.R.Add(a.R, b.R)
-	a.I.Add(a.I, b.I)
+	a.prepare(b, nil, nil)
+	s := a.scratch
+
+	s.a.Add(a.R, b.R)
+	s.b.Add(a.I, b.I)
+
+	a.R.Set(&s.a)
+	a.I.Set(&s.b)
 	return a
 }
 
@@ -46,8 +54,9 @@ func (a *ComplexBig) Abs() (z *big.Float) {
 	return r.Sqrt(r)
 }
 
+// String formats z with 10 significant digits, see Text
 func (z *ComplexBig) String() string {
-	return z.R.String() + "+" + z.I.String() + "i"
+	return z.Text('g', 10)
 }
 func (a *ComplexBig) Copy() *ComplexBig {
 	return &ComplexBig{R: new(big.Float).Set(a.R), I: new(big.Float).Set(a.I)}
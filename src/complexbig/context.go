@@ -0,0 +1,119 @@
+package complexbig
+
+import (
+	"math"
+	"math/big"
+)
+
+// GuardBits are added by PrecisionForPixelSize to the precision that is
+// needed to resolve a single pixel, so that rounding errors accumulated
+// during the iteration stay below the pixel size. They are few, because the
+// fast backends are chosen by the same precision, see
+// core.BackendForPrecision.
+const GuardBits = 8
+
+// Context defines the precision and rounding mode that is used for all
+// results and intermediates of its operations
+type Context struct {
+	Prec uint
+	Mode big.RoundingMode
+}
+
+// NewContext creates a context with the given precision that rounds to the
+// nearest even value
+func NewContext(prec uint) *Context {
+	return &Context{Prec: prec, Mode: big.ToNearestEven}
+}
+
+// ContextOf creates a context with the highest precision of a's parts
+func ContextOf(a *ComplexBig) *Context {
+	prec := a.R.Prec()
+	if a.I.Prec() > prec {
+		prec = a.I.Prec()
+	}
+	return NewContext(prec)
+}
+
+// ContextForPixelSize creates a context that can resolve points pixelSize
+// apart, see PrecisionForPixelSize
+func ContextForPixelSize(pixelSize *big.Float) *Context {
+	return NewContext(PrecisionForPixelSize(pixelSize))
+}
+
+// PrecisionForPixelSize returns the precision in bits that is needed to
+// resolve points pixelSize apart, see PixelBits, plus GuardBits. It is never
+// less than float64 precision.
+func PrecisionForPixelSize(pixelSize *big.Float) uint {
+	bits := PixelBits(pixelSize) + GuardBits
+	if bits < 53 {
+		return 53
+	}
+	return bits
+}
+
+// PixelBits returns the number of bits that are needed to tell points
+// pixelSize apart whose absolute value is at most 2, without guard bits
+func PixelBits(pixelSize *big.Float) uint {
+	mant := new(big.Float)
+	exp := pixelSize.MantExp(mant)
+	m, _ := mant.Float64()
+
+	// log2(2 / pixelSize)
+	bits := math.Ceil(1 - math.Log2(math.Abs(m)) - float64(exp))
+	if bits < 0 {
+		return 0
+	}
+	return uint(bits)
+}
+
+// NewFloat returns a big.Float with the precision and rounding mode of the
+// context
+func (ctx *Context) NewFloat(x float64) *big.Float {
+	return new(big.Float).SetPrec(ctx.Prec).SetMode(ctx.Mode).SetFloat64(x)
+}
+
+func (ctx *Context) float() *big.Float {
+	return new(big.Float).SetPrec(ctx.Prec).SetMode(ctx.Mode)
+}
+
+// New creates r+i*i with the precision of the context
+func (ctx *Context) New(r, i float64) *ComplexBig {
+	return &ComplexBig{R: ctx.NewFloat(r), I: ctx.NewFloat(i)}
+}
+
+// Set returns a copy of a rounded to the precision of the context
+func (ctx *Context) Set(a *ComplexBig) *ComplexBig {
+	return &ComplexBig{R: ctx.float().Set(a.R), I: ctx.float().Set(a.I)}
+}
+
+// Mul a*b=z
+func (ctx *Context) Mul(a, b *ComplexBig) (z *ComplexBig) {
+	// newR = a.R * b.R - a.I * b.I
+	newR := ctx.float().Sub(
+		ctx.float().Mul(a.R, b.R),
+		ctx.float().Mul(a.I, b.I))
+	newI := ctx.float().Add(
+		ctx.float().Mul(a.I, b.R),
+		ctx.float().Mul(a.R, b.I))
+	return &ComplexBig{R: newR, I: newI}
+}
+
+// Add a+b=z
+func (ctx *Context) Add(a, b *ComplexBig) (z *ComplexBig) {
+	return &ComplexBig{R: ctx.float().Add(a.R, b.R), I: ctx.float().Add(a.I, b.I)}
+}
+
+// Abs gets the absolute value of a
+func (ctx *Context) Abs(a *ComplexBig) (z *big.Float) {
+	r := ctx.float().Mul(a.R, a.R)
+	i := ctx.float().Mul(a.I, a.I)
+
+	r.Add(r, i)
+
+	return r.Sqrt(r)
+}
+
+// MirrorImaginary returns the complex conjugate of a
+func (ctx *Context) MirrorImaginary(a *ComplexBig) *ComplexBig {
+	return &ComplexBig{R: ctx.float().Set(a.R), I: ctx.float().Neg(a.I)}
+}
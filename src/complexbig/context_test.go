@@ -0,0 +1,79 @@
+package complexbig
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestContextPrecision(t *testing.T) {
+	ctx := NewContext(200)
+	a := &ComplexBig{R: big.NewFloat(1.5), I: big.NewFloat(-0.25)}
+	b := &ComplexBig{R: big.NewFloat(0.1), I: big.NewFloat(3)}
+
+	results := []*ComplexBig{ctx.Mul(a, b), ctx.Add(a, b), ctx.Set(a), ctx.MirrorImaginary(a), ctx.New(1, 2)}
+	for _, z := range results {
+		if z.R.Prec() != 200 || z.I.Prec() != 200 {
+			t.Fatalf("expected precision 200, got %v and %v", z.R.Prec(), z.I.Prec())
+		}
+	}
+	if ctx.Abs(a).Prec() != 200 {
+		t.Fatalf("expected precision 200, got %v", ctx.Abs(a).Prec())
+	}
+
+	z := ctx.Mul(a, b)
+	if z.R.Cmp(big.NewFloat(0.9)) == 0 {
+		t.Fatalf("expected 0.1 to keep its float64 rounding error")
+	}
+	expected := new(big.Float).SetPrec(200).Mul(big.NewFloat(1.5), big.NewFloat(0.1))
+	expected.Add(expected, big.NewFloat(0.75))
+	if z.R.Cmp(expected) != 0 {
+		t.Fatalf("expected %v, got %v", expected, z.R)
+	}
+}
+
+func TestContextRoundingMode(t *testing.T) {
+	third := new(big.Float).SetPrec(100).Quo(big.NewFloat(1), big.NewFloat(3))
+	a := &ComplexBig{R: third, I: third}
+
+	down := &Context{Prec: 10, Mode: big.ToZero}
+	up := &Context{Prec: 10, Mode: big.AwayFromZero}
+
+	if down.Set(a).R.Cmp(up.Set(a).R) != -1 {
+		t.Fatalf("expected rounding towards zero to be smaller")
+	}
+	if down.Add(a, a).R.Cmp(up.Add(a, a).R) != -1 {
+		t.Fatalf("expected rounding towards zero to be smaller")
+	}
+}
+
+func TestContextOf(t *testing.T) {
+	a := &ComplexBig{R: new(big.Float).SetPrec(80), I: new(big.Float).SetPrec(120)}
+	if ContextOf(a).Prec != 120 {
+		t.Fatalf("expected 120, got %v", ContextOf(a).Prec)
+	}
+}
+
+func TestPrecisionForPixelSize(t *testing.T) {
+	if p := PrecisionForPixelSize(big.NewFloat(0.004)); p != 53 {
+		t.Fatalf("expected at least float64 precision, got %v", p)
+	}
+
+	// log2(2 / 2^-200) = 201
+	pixelSize := new(big.Float).SetMantExp(big.NewFloat(1), -200)
+	if p := PrecisionForPixelSize(pixelSize); p != 201+GuardBits {
+		t.Fatalf("expected %v, got %v", 201+GuardBits, p)
+	}
+	if b := PixelBits(pixelSize); b != 201 {
+		t.Fatalf("expected 201 bits without guard bits, got %v", b)
+	}
+
+	pixelSize, _, _ = big.ParseFloat("3e-1000", 10, 64, big.ToNearestEven)
+	ctx := ContextForPixelSize(pixelSize)
+
+	// points a pixel apart can be told apart
+	a := ctx.New(-1.5, 0)
+	b := ctx.Add(a, &ComplexBig{R: pixelSize, I: new(big.Float)})
+	if a.Equals(b) {
+		t.Fatalf("expected points a pixel apart to differ at precision %v", ctx.Prec)
+	}
+}
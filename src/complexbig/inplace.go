@@ -0,0 +1,149 @@
+package complexbig
+
+import "math/big"
+
+// scratch holds the intermediates of in-place operations, so that they can be
+// reused instead of allocated for every operation. big.Float allocates if the
+// result of an addition is one of its operands, so sums are always computed
+// into a different buffer.
+type scratch struct {
+	a, b, c, d, e big.Float
+}
+
+// prepare makes sure that z and its scratch buffers exist and returns the
+// precision for the results, which is the precision of z or, if z has none
+// yet, the highest precision of the operands. Unused operands are nil.
+func (z *ComplexBig) prepare(a, b, c *ComplexBig) uint {
+	if z.R == nil {
+		z.R = new(big.Float)
+	}
+	if z.I == nil {
+		z.I = new(big.Float)
+	}
+
+	prec := z.R.Prec()
+	if prec == 0 {
+		prec = maxPrec(maxPrec(maxPrec(0, a), b), c)
+		z.R.SetPrec(prec)
+		z.I.SetPrec(prec)
+	}
+
+	if z.scratch == nil {
+		z.scratch = &scratch{}
+	}
+	s := z.scratch
+	mode := z.R.Mode()
+	s.a.SetPrec(prec).SetMode(mode)
+	s.b.SetPrec(prec).SetMode(mode)
+	s.c.SetPrec(prec).SetMode(mode)
+	s.d.SetPrec(prec).SetMode(mode)
+	s.e.SetPrec(prec).SetMode(mode)
+	return prec
+}
+
+func maxPrec(prec uint, a *ComplexBig) uint {
+	if a == nil {
+		return prec
+	}
+	if a.R.Prec() > prec {
+		prec = a.R.Prec()
+	}
+	if a.I.Prec() > prec {
+		prec = a.I.Prec()
+	}
+	return prec
+}
+
+// Set sets z to a, rounded to the precision of z
+func (z *ComplexBig) Set(a *ComplexBig) *ComplexBig {
+	z.prepare(a, nil, nil)
+	z.R.Set(a.R)
+	z.I.Set(a.I)
+	return z
+}
+
+// Mul sets z to a*b without allocations, z may be a or b
+func (z *ComplexBig) Mul(a, b *ComplexBig) *ComplexBig {
+	z.prepare(a, b, nil)
+	s := z.scratch
+
+	// r = a.R * b.R - a.I * b.I
+	s.a.Mul(a.R, b.R)
+	s.b.Mul(a.I, b.I)
+	// i = a.I * b.R + a.R * b.I
+	s.c.Mul(a.I, b.R)
+	s.d.Mul(a.R, b.I)
+
+	z.R.Sub(&s.a, &s.b)
+	z.I.Add(&s.c, &s.d)
+	return z
+}
+
+// Sqr sets z to a*a without allocations, z may be a. It needs three instead
+// of four multiplications.
+func (z *ComplexBig) Sqr(a *ComplexBig) *ComplexBig {
+	z.prepare(a, nil, nil)
+	s := z.scratch
+
+	// r = a.R * a.R - a.I * a.I
+	s.a.Mul(a.R, a.R)
+	s.b.Mul(a.I, a.I)
+	// i = 2 * a.R * a.I
+	s.c.Mul(a.R, a.I)
+
+	z.R.Sub(&s.a, &s.b)
+	z.I.Add(&s.c, &s.c)
+	return z
+}
+
+// MulAdd sets z to a*b+c without allocations, z may be any of the operands
+func (z *ComplexBig) MulAdd(a, b, c *ComplexBig) *ComplexBig {
+	z.prepare(a, b, c)
+	s := z.scratch
+
+	s.a.Mul(a.R, b.R)
+	s.b.Mul(a.I, b.I)
+	s.c.Mul(a.I, b.R)
+	s.d.Mul(a.R, b.I)
+
+	s.e.Sub(&s.a, &s.b)
+	s.a.Add(&s.e, c.R)
+	s.e.Add(&s.c, &s.d)
+	s.b.Add(&s.e, c.I)
+
+	z.R.Set(&s.a)
+	z.I.Set(&s.b)
+	return z
+}
+
+// SqrAdd sets z to a*a+c without allocations, z may be any of the operands.
+// This is a single step of the iteration of the Mandelbrot set.
+func (z *ComplexBig) SqrAdd(a, c *ComplexBig) *ComplexBig {
+	z.prepare(a, c, nil)
+	s := z.scratch
+
+	s.a.Mul(a.R, a.R)
+	s.b.Mul(a.I, a.I)
+	s.c.Mul(a.R, a.I)
+
+	s.d.Sub(&s.a, &s.b)
+	s.e.Add(&s.c, &s.c)
+	s.a.Add(&s.d, c.R)
+	s.b.Add(&s.e, c.I)
+
+	z.R.Set(&s.a)
+	z.I.Set(&s.b)
+	return z
+}
+
+// AbsSq returns |z|^2 without allocations and without a square root. The
+// result is stored in z's scratch buffers, so it is only valid until the
+// next in-place operation on z.
+func (z *ComplexBig) AbsSq() *big.Float {
+	z.prepare(nil, nil, nil)
+	s := z.scratch
+
+	s.a.Mul(z.R, z.R)
+	s.b.Mul(z.I, z.I)
+	return s.c.Add(&s.a, &s.b)
+}
@@ -0,0 +1,147 @@
+package complexbig
+
+import (
+	"math/big"
+	"testing"
+)
+
+func newComplex(r, i float64) *ComplexBig {
+	return &ComplexBig{R: big.NewFloat(r), I: big.NewFloat(i)}
+}
+
+func expectComplex(t *testing.T, z *ComplexBig, r, i float64) {
+	t.Helper()
+	if z.R.Cmp(big.NewFloat(r)) != 0 || z.I.Cmp(big.NewFloat(i)) != 0 {
+		t.Fatalf("expected %v+%vi, got %v", r, i, z)
+	}
+}
+
+func TestInPlaceMul(t *testing.T) {
+	a := newComplex(2, 4)
+	b := newComplex(3, 5)
+
+	expectComplex(t, new(ComplexBig).Mul(a, b), -14, 22)
+
+	// z may be one of the operands
+	a.Mul(a, b)
+	expectComplex(t, a, -14, 22)
+
+	b.Mul(b, b)
+	expectComplex(t, b, -16, 30)
+}
+
+func TestInPlaceSqr(t *testing.T) {
+	a := newComplex(6, -3)
+	expectComplex(t, new(ComplexBig).Sqr(a), 27, -36)
+
+	a.Sqr(a)
+	expectComplex(t, a, 27, -36)
+}
+
+func TestInPlaceMulAdd(t *testing.T) {
+	a := newComplex(6, 3)
+	b := newComplex(7, -1)
+	c := newComplex(-5, 2)
+
+	expectComplex(t, new(ComplexBig).MulAdd(a, b, c), 40, 17)
+
+	c.MulAdd(a, b, c)
+	expectComplex(t, c, 40, 17)
+}
+
+func TestInPlaceSqrAdd(t *testing.T) {
+	a := newComplex(6, -3)
+	c := newComplex(-5, 2)
+
+	expectComplex(t, new(ComplexBig).SqrAdd(a, c), 22, -34)
+
+	c.SqrAdd(a, c)
+	expectComplex(t, c, 22, -34)
+
+	a.SqrAdd(a, a)
+	expectComplex(t, a, 33, -39)
+}
+
+func TestInPlaceAdd(t *testing.T) {
+	a := newComplex(2, 52)
+	a.Add(newComplex(-5, -2))
+	expectComplex(t, a, -3, 50)
+
+	a.Add(a)
+	expectComplex(t, a, -6, 100)
+}
+
+func TestInPlaceAbsSq(t *testing.T) {
+	a := newComplex(5, 12)
+	if a.AbsSq().Cmp(big.NewFloat(169)) != 0 {
+		t.Fatalf("expected 169, got %v", a.AbsSq())
+	}
+}
+
+func TestInPlacePrecision(t *testing.T) {
+	ctx := NewContext(150)
+	z := ctx.New(0, 0)
+	z.Mul(newComplex(1, 2), newComplex(3, 4))
+	if z.R.Prec() != 150 || z.I.Prec() != 150 {
+		t.Fatalf("expected precision 150, got %v and %v", z.R.Prec(), z.I.Prec())
+	}
+
+	// without precision z takes the highest precision of the operands
+	a := ctx.New(1, 2)
+	z = new(ComplexBig).Sqr(a)
+	if z.R.Prec() != 150 {
+		t.Fatalf("expected precision 150, got %v", z.R.Prec())
+	}
+}
+
+func TestInPlaceAllocations(t *testing.T) {
+	a := newComplex(0.3, -0.7)
+	b := newComplex(1.1, 0.4)
+	z := new(ComplexBig).Set(a)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		z.Mul(z, b)
+		z.Sqr(z)
+		z.MulAdd(z, a, b)
+		z.SqrAdd(z, a)
+		z.Add(b)
+		z.AbsSq()
+		z.Set(a)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	z := newComplex(0.3, -0.7)
+	c := newComplex(0.5, 0.2)
+	for i := 0; i < b.N; i++ {
+		z = Mul(z, c)
+		z.Set(c)
+	}
+}
+
+func BenchmarkInPlaceMul(b *testing.B) {
+	z := newComplex(0.3, -0.7)
+	c := newComplex(0.5, 0.2)
+	for i := 0; i < b.N; i++ {
+		z.Mul(z, c)
+		z.Set(c)
+	}
+}
+
+func BenchmarkAbs(b *testing.B) {
+	z := newComplex(0.3, -0.7)
+	for i := 0; i < b.N; i++ {
+		z.Abs().Cmp(Two)
+	}
+}
+
+func BenchmarkAbsSq(b *testing.B) {
+	z := newComplex(0.3, -0.7)
+	four := big.NewFloat(4)
+	for i := 0; i < b.N; i++ {
+		z.AbsSq().Cmp(four)
+	}
+}
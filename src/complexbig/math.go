@@ -0,0 +1,163 @@
+package complexbig
+
+import "math/big"
+
+// The functions in this file compute their results with additional guard
+// bits and round them to the precision of the context.
+
+func (ctx *Context) round(r, i *big.Float) *ComplexBig {
+	return &ComplexBig{R: ctx.float().Set(r), I: ctx.float().Set(i)}
+}
+
+func (ctx *Context) workingPrec() uint {
+	return ctx.Prec + realGuardBits
+}
+
+// Sub a-b=z
+func (ctx *Context) Sub(a, b *ComplexBig) (z *ComplexBig) {
+	return &ComplexBig{R: ctx.float().Sub(a.R, b.R), I: ctx.float().Sub(a.I, b.I)}
+}
+
+// Neg -a=z
+func (ctx *Context) Neg(a *ComplexBig) (z *ComplexBig) {
+	return &ComplexBig{R: ctx.float().Neg(a.R), I: ctx.float().Neg(a.I)}
+}
+
+// Conj returns the complex conjugate of a, it is the same as MirrorImaginary
+func (ctx *Context) Conj(a *ComplexBig) (z *ComplexBig) {
+	return ctx.MirrorImaginary(a)
+}
+
+// Div a/b=z, b must not be zero
+func (ctx *Context) Div(a, b *ComplexBig) (z *ComplexBig) {
+	wp := ctx.workingPrec()
+
+	// a/b = a*conj(b) / |b|^2
+	den := newFloat(wp).Mul(b.R, b.R)
+	den.Add(den, newFloat(wp).Mul(b.I, b.I))
+
+	r := newFloat(wp).Mul(a.R, b.R)
+	r.Add(r, newFloat(wp).Mul(a.I, b.I))
+	r.Quo(r, den)
+
+	i := newFloat(wp).Mul(a.I, b.R)
+	i.Sub(i, newFloat(wp).Mul(a.R, b.I))
+	i.Quo(i, den)
+
+	return ctx.round(r, i)
+}
+
+// Pow a^n=z for integer n, a must not be zero if n is negative
+func (ctx *Context) Pow(a *ComplexBig, n int) (z *ComplexBig) {
+	wp := NewContext(ctx.workingPrec())
+
+	// square and multiply
+	result := wp.New(1, 0)
+	base := wp.Set(a)
+	e := n
+	if e < 0 {
+		e = -e
+	}
+	for e > 0 {
+		if e&1 == 1 {
+			result.Mul(result, base)
+		}
+		base.Sqr(base)
+		e >>= 1
+	}
+
+	if n < 0 {
+		result = wp.Div(wp.New(1, 0), result)
+	}
+	return ctx.round(result.R, result.I)
+}
+
+// Sqrt returns the principal square root of a, the branch cut is the
+// negative real axis like in math/cmplx
+func (ctx *Context) Sqrt(a *ComplexBig) (z *ComplexBig) {
+	wp := ctx.workingPrec()
+
+	if a.R.Sign() == 0 && a.I.Sign() == 0 {
+		return ctx.round(newFloat(wp), a.I)
+	}
+
+	abs := newFloat(wp).Mul(a.R, a.R)
+	abs.Add(abs, newFloat(wp).Mul(a.I, a.I))
+	abs.Sqrt(abs)
+
+	// t = sqrt((|a| + |a.R|) / 2) is computed without cancellation, the
+	// other part follows from 2*t*other = a.I
+	t := newFloat(wp).Abs(a.R)
+	t.Add(t, abs)
+	t.SetMantExp(t, -1)
+	t.Sqrt(t)
+
+	other := newFloat(wp).Quo(a.I, t)
+	other.SetMantExp(other, -1)
+
+	if a.R.Sign() >= 0 {
+		return ctx.round(t, other)
+	}
+	if a.I.Signbit() {
+		t.Neg(t)
+	}
+	return ctx.round(other.Abs(other), t)
+}
+
+// Exp returns e^a, it panics with ErrNaN if the imaginary part of a is
+// infinite
+func (ctx *Context) Exp(a *ComplexBig) (z *ComplexBig) {
+	wp := ctx.workingPrec()
+
+	// e^(x+iy) = e^x * (cos(y) + i sin(y))
+	e := exp(a.R, wp)
+	sin, cos := sinCos(a.I, wp)
+
+	return ctx.round(cos.Mul(cos, e), sin.Mul(sin, e))
+}
+
+// Log returns the principal natural logarithm of a, its imaginary part is in
+// (-π, π]
+func (ctx *Context) Log(a *ComplexBig) (z *ComplexBig) {
+	wp := ctx.workingPrec()
+
+	// log(a) = log(|a|) + i*arg(a) = log(|a|^2)/2 + i*arg(a)
+	absSq := newFloat(wp).Mul(a.R, a.R)
+	absSq.Add(absSq, newFloat(wp).Mul(a.I, a.I))
+	r := log(absSq, wp)
+	if !r.IsInf() {
+		r.SetMantExp(r, -1)
+	}
+
+	return ctx.round(r, atan2(a.I, a.R, wp))
+}
+
+// Sin returns the sine of a, it panics with ErrNaN if the real part of a is
+// infinite
+func (ctx *Context) Sin(a *ComplexBig) (z *ComplexBig) {
+	wp := ctx.workingPrec()
+
+	// sin(x+iy) = sin(x)cosh(y) + i cos(x)sinh(y)
+	sin, cos := sinCos(a.R, wp)
+	sinh, cosh := sinhCosh(a.I, wp)
+
+	return ctx.round(sin.Mul(sin, cosh), cos.Mul(cos, sinh))
+}
+
+// Cos returns the cosine of a, it panics with ErrNaN if the real part of a
+// is infinite
+func (ctx *Context) Cos(a *ComplexBig) (z *ComplexBig) {
+	wp := ctx.workingPrec()
+
+	// cos(x+iy) = cos(x)cosh(y) - i sin(x)sinh(y)
+	sin, cos := sinCos(a.R, wp)
+	sinh, cosh := sinhCosh(a.I, wp)
+
+	i := sin.Mul(sin, sinh)
+	return ctx.round(cos.Mul(cos, cosh), i.Neg(i))
+}
+
+// Arg returns the argument of a in (-π, π]
+func (ctx *Context) Arg(a *ComplexBig) *big.Float {
+	return atan2(a.I, a.R, ctx.Prec)
+}
@@ -0,0 +1,154 @@
+package complexbig
+
+import (
+	"math"
+	"math/big"
+	"math/cmplx"
+	"testing"
+)
+
+var mathPoints = []complex128{
+	complex(0.5, 0.25),
+	complex(-1.75, 0.3),
+	complex(2, -3),
+	complex(-0.001, -7.5),
+	complex(12.5, 0.01),
+	complex(-4, 0),
+	complex(0, 1),
+	complex(1e-10, -2e-12),
+}
+
+func fromComplex(c complex128) *ComplexBig {
+	return &ComplexBig{R: big.NewFloat(real(c)), I: big.NewFloat(imag(c))}
+}
+
+func toComplex(z *ComplexBig) complex128 {
+	r, _ := z.R.Float64()
+	i, _ := z.I.Float64()
+	return complex(r, i)
+}
+
+// expectClose compares z to math/cmplx, which is accurate to a few ulps
+func expectClose(t *testing.T, name string, c complex128, z *ComplexBig, expected complex128) {
+	t.Helper()
+	actual := toComplex(z)
+	if cmplx.Abs(actual-expected) > 1e-14*cmplx.Abs(expected)+1e-300 {
+		t.Fatalf("%v(%v): expected %v, got %v", name, c, expected, actual)
+	}
+}
+
+func TestMathMatchesCmplx(t *testing.T) {
+	ctx := NewContext(53)
+
+	unary := []struct {
+		name     string
+		fn       func(*ComplexBig) *ComplexBig
+		expected func(complex128) complex128
+	}{
+		{"Neg", ctx.Neg, func(c complex128) complex128 { return -c }},
+		{"Conj", ctx.Conj, cmplx.Conj},
+		{"Sqrt", ctx.Sqrt, cmplx.Sqrt},
+		{"Exp", ctx.Exp, cmplx.Exp},
+		{"Log", ctx.Log, cmplx.Log},
+		{"Sin", ctx.Sin, cmplx.Sin},
+		{"Cos", ctx.Cos, cmplx.Cos},
+	}
+
+	for _, c := range mathPoints {
+		for _, f := range unary {
+			expectClose(t, f.name, c, f.fn(fromComplex(c)), f.expected(c))
+		}
+
+		arg, _ := ctx.Arg(fromComplex(c)).Float64()
+		if math.Abs(arg-cmplx.Phase(c)) > 1e-15*math.Abs(cmplx.Phase(c)) {
+			t.Fatalf("Arg(%v): expected %v, got %v", c, cmplx.Phase(c), arg)
+		}
+
+		for _, n := range []int{-3, 0, 1, 2, 7} {
+			expectClose(t, "Pow", c, ctx.Pow(fromComplex(c), n), cmplx.Pow(c, complex(float64(n), 0)))
+		}
+
+		for _, d := range mathPoints {
+			expectClose(t, "Sub", c, ctx.Sub(fromComplex(c), fromComplex(d)), c-d)
+			expectClose(t, "Div", c, ctx.Div(fromComplex(c), fromComplex(d)), c/d)
+		}
+	}
+}
+
+func TestMathBranchCuts(t *testing.T) {
+	ctx := NewContext(53)
+
+	cases := []complex128{complex(-1, 0), complex(-2, math.Copysign(0, -1)), complex(0, 0)}
+	for _, c := range cases {
+		expectClose(t, "Sqrt", c, ctx.Sqrt(fromComplex(c)), cmplx.Sqrt(c))
+
+		arg, _ := ctx.Arg(fromComplex(c)).Float64()
+		if arg != cmplx.Phase(c) {
+			t.Fatalf("Arg(%v): expected %v, got %v", c, cmplx.Phase(c), arg)
+		}
+	}
+
+	if l := ctx.Log(fromComplex(0)); !l.R.IsInf() || l.R.Sign() > 0 {
+		t.Fatalf("expected log(0) to be -Inf, got %v", l)
+	}
+}
+
+func TestMathInfiniteArguments(t *testing.T) {
+	ctx := NewContext(53)
+	inf := math.Inf(1)
+
+	cases := map[string]func() *ComplexBig{
+		"Sin(Inf)":   func() *ComplexBig { return ctx.Sin(fromComplex(complex(inf, 0))) },
+		"Cos(-Inf)":  func() *ComplexBig { return ctx.Cos(fromComplex(complex(-inf, 1))) },
+		"Exp(Inf*i)": func() *ComplexBig { return ctx.Exp(fromComplex(complex(0, inf))) },
+	}
+	for name, f := range cases {
+		func() {
+			defer func() {
+				if _, ok := recover().(ErrNaN); !ok {
+					t.Fatalf("%v: expected a panic with ErrNaN", name)
+				}
+			}()
+			f()
+		}()
+	}
+
+	// infinite parts that do not lead to NaN are fine
+	if e := ctx.Exp(fromComplex(complex(-inf, 1))); e.R.Sign() != 0 || e.I.Sign() != 0 {
+		t.Fatalf("expected e^(-Inf+i) to be 0, got %v", e)
+	}
+}
+
+func TestMathHighPrecision(t *testing.T) {
+	ctx := NewContext(500)
+	tolerance := new(big.Float).SetMantExp(big.NewFloat(1), -490)
+
+	expectEqual := func(name string, a, b *ComplexBig) {
+		t.Helper()
+		diff := ctx.Sub(a, b)
+		if new(big.Float).Abs(diff.R).Cmp(tolerance) > 0 || new(big.Float).Abs(diff.I).Cmp(tolerance) > 0 {
+			t.Fatalf("%v: expected %v, got %v", name, a, b)
+		}
+	}
+
+	for _, c := range mathPoints {
+		z := ctx.Set(fromComplex(c))
+
+		expectEqual("Exp(Log(z))", z, ctx.Exp(ctx.Log(z)))
+		expectEqual("Sqrt(z)^2", z, ctx.Pow(ctx.Sqrt(z), 2))
+		expectEqual("Div(Mul(z, w), w)", z, ctx.Div(ctx.Mul(z, fromComplex(3-2i)), fromComplex(3-2i)))
+
+		// sin(z)^2 + cos(z)^2 = 1
+		sin, cos := ctx.Sin(z), ctx.Cos(z)
+		expectEqual("sin^2+cos^2", ctx.New(1, 0), ctx.Add(ctx.Mul(sin, sin), ctx.Mul(cos, cos)))
+	}
+
+	// π = 4 atan(1) = arg(1+i) * 4
+	p := ctx.Arg(ctx.New(1, 1))
+	p.Mul(p, big.NewFloat(4))
+	piDigits := "3.14159265358979323846264338327950288419716939937510582097494459230781640628620899862803482534211706798"
+	expected, _, _ := big.ParseFloat(piDigits, 10, 500, big.ToNearestEven)
+	if p.Text('g', 100) != expected.Text('g', 100) {
+		t.Fatalf("expected %v, got %v", expected.Text('g', 100), p.Text('g', 100))
+	}
+}
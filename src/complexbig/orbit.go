@@ -0,0 +1,307 @@
+package complexbig
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+)
+
+// An orbit file starts with a header followed by the points of the orbit.
+// All numbers are stored with the precision of the header: a flags byte for
+// the kind and sign, the binary exponent as varint and the mantissa in
+// (prec+7)/8 big endian bytes. Zeros and infinities have no exponent and
+// mantissa. The number of points is not stored, so orbits can be written
+// while they are computed.
+//
+//	header = "ORBT" version prec mode maxIt c
+//	point  = real imaginary
+var orbitMagic = []byte("ORBT")
+
+const orbitVersion = 1
+
+// MaxOrbitPrec is the highest precision of an orbit file. It bounds the
+// memory that the header of a file can make a reader allocate, a point takes
+// about 2*MaxOrbitPrec/8 bytes.
+const MaxOrbitPrec = 1 << 20
+
+const (
+	kindZero byte = iota
+	kindFinite
+	kindInf
+
+	flagNeg byte = 1 << 7
+)
+
+// OrbitHeader describes the orbit that is stored in an orbit file
+type OrbitHeader struct {
+	// Prec and Mode are the precision and rounding mode of all points
+	Prec uint
+	Mode big.RoundingMode
+	// C is the point that was iterated
+	C *ComplexBig
+	// MaxIt is the iteration limit the orbit was computed with
+	MaxIt int
+}
+
+// OrbitWriter writes an orbit file point by point
+type OrbitWriter struct {
+	w    *bufio.Writer
+	prec uint
+	mode big.RoundingMode
+	buf  []byte
+}
+
+// NewOrbitWriter writes the header to w and returns a writer for the points,
+// Flush has to be called after the last point
+func NewOrbitWriter(w io.Writer, header OrbitHeader) (*OrbitWriter, error) {
+	if err := checkOrbitFormat(uint64(header.Prec), header.Mode); err != nil {
+		return nil, err
+	}
+	if header.C == nil {
+		return nil, errors.New("complexbig: orbit header without a point")
+	}
+
+	ow := &OrbitWriter{w: bufio.NewWriter(w), prec: header.Prec, mode: header.Mode}
+	ow.buf = append(ow.buf, orbitMagic...)
+	ow.buf = append(ow.buf, orbitVersion)
+	ow.buf = appendUvarint(ow.buf, uint64(header.Prec))
+	ow.buf = append(ow.buf, byte(header.Mode))
+	ow.buf = appendUvarint(ow.buf, uint64(header.MaxIt))
+	if _, err := ow.w.Write(ow.buf); err != nil {
+		return nil, err
+	}
+	if err := ow.Write(header.C); err != nil {
+		return nil, err
+	}
+	return ow, nil
+}
+
+// Write appends z rounded to the precision and with the rounding mode of the
+// header
+func (ow *OrbitWriter) Write(z *ComplexBig) error {
+	ow.buf = ow.appendFloat(ow.buf[:0], z.R)
+	ow.buf = ow.appendFloat(ow.buf, z.I)
+	_, err := ow.w.Write(ow.buf)
+	return err
+}
+
+// Flush writes buffered points to the underlying writer
+func (ow *OrbitWriter) Flush() error {
+	return ow.w.Flush()
+}
+
+func (ow *OrbitWriter) appendFloat(buf []byte, x *big.Float) []byte {
+	var flags byte
+	if x.Signbit() {
+		flags = flagNeg
+	}
+	switch {
+	case x.IsInf():
+		return append(buf, kindInf|flags)
+	case x.Sign() == 0:
+		return append(buf, kindZero|flags)
+	}
+
+	// the mantissa as an integer with prec bits
+	mant := new(big.Float).SetPrec(ow.prec).SetMode(ow.mode).Set(x)
+	exp := mant.MantExp(mant)
+	mant.SetMantExp(mant.Abs(mant), int(ow.prec))
+	mantInt, _ := mant.Int(nil)
+
+	buf = append(buf, kindFinite|flags)
+	buf = appendVarint(buf, int64(exp))
+	n := len(buf)
+	buf = append(buf, make([]byte, mantBytes(ow.prec))...)
+	mantInt.FillBytes(buf[n:])
+	return buf
+}
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutUvarint(b[:], x)]...)
+}
+
+func appendVarint(buf []byte, x int64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutVarint(b[:], x)]...)
+}
+
+// checkOrbitFormat checks the precision and rounding mode of an orbit header
+func checkOrbitFormat(prec uint64, mode big.RoundingMode) error {
+	if prec == 0 || prec > MaxOrbitPrec {
+		return fmt.Errorf("complexbig: invalid orbit precision %v, it has to be in [1, %v]", prec, MaxOrbitPrec)
+	}
+	if mode > big.ToPositiveInf {
+		return fmt.Errorf("complexbig: invalid orbit rounding mode %v", mode)
+	}
+	return nil
+}
+
+func mantBytes(prec uint) int {
+	return int(prec+7) / 8
+}
+
+// OrbitReader reads an orbit file point by point
+type OrbitReader struct {
+	r      *bufio.Reader
+	header OrbitHeader
+	buf    []byte
+}
+
+// NewOrbitReader reads the header from r and returns a reader for the points
+func NewOrbitReader(r io.Reader) (*OrbitReader, error) {
+	or := &OrbitReader{r: bufio.NewReader(r)}
+
+	magic := make([]byte, len(orbitMagic)+1)
+	if _, err := io.ReadFull(or.r, magic); err != nil {
+		return nil, fmt.Errorf("complexbig: reading orbit header: %w", err)
+	}
+	if string(magic[:len(orbitMagic)]) != string(orbitMagic) {
+		return nil, errors.New("complexbig: not an orbit file")
+	}
+	if magic[len(orbitMagic)] != orbitVersion {
+		return nil, fmt.Errorf("complexbig: unsupported orbit version %v", magic[len(orbitMagic)])
+	}
+
+	prec, err := binary.ReadUvarint(or.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	mode, err := or.r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if err := checkOrbitFormat(prec, big.RoundingMode(mode)); err != nil {
+		return nil, err
+	}
+	maxIt, err := binary.ReadUvarint(or.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	or.header = OrbitHeader{Prec: uint(prec), Mode: big.RoundingMode(mode), MaxIt: int(maxIt)}
+	or.buf = make([]byte, mantBytes(or.header.Prec))
+
+	if or.header.C, err = or.Read(); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return or, nil
+}
+
+// Header returns the header of the orbit file
+func (or *OrbitReader) Header() OrbitHeader {
+	return or.header
+}
+
+// Read returns the next point of the orbit or io.EOF after the last one
+func (or *OrbitReader) Read() (*ComplexBig, error) {
+	r, err := or.readFloat()
+	if err != nil {
+		// EOF is only expected between points
+		return nil, err
+	}
+	i, err := or.readFloat()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return &ComplexBig{R: r, I: i}, nil
+}
+
+func (or *OrbitReader) readFloat() (*big.Float, error) {
+	flags, err := or.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Float).SetPrec(or.header.Prec).SetMode(or.header.Mode)
+
+	switch flags &^ flagNeg {
+	case kindZero:
+	case kindInf:
+		x.SetInf(false)
+	case kindFinite:
+		exp, err := binary.ReadVarint(or.r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if _, err := io.ReadFull(or.r, or.buf); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		x.SetInt(new(big.Int).SetBytes(or.buf))
+		x.SetMantExp(x, int(exp)-int(or.header.Prec))
+	default:
+		return nil, fmt.Errorf("complexbig: invalid orbit number kind %v", flags)
+	}
+
+	if flags&flagNeg != 0 {
+		x.Neg(x)
+	}
+	return x, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// WriteOrbit writes a complete orbit file
+func WriteOrbit(w io.Writer, header OrbitHeader, orbit []*ComplexBig) error {
+	ow, err := NewOrbitWriter(w, header)
+	if err != nil {
+		return err
+	}
+	for _, z := range orbit {
+		if err := ow.Write(z); err != nil {
+			return err
+		}
+	}
+	return ow.Flush()
+}
+
+// ReadOrbit reads a complete orbit file
+func ReadOrbit(r io.Reader) (OrbitHeader, []*ComplexBig, error) {
+	or, err := NewOrbitReader(r)
+	if err != nil {
+		return OrbitHeader{}, nil, err
+	}
+
+	orbit := make([]*ComplexBig, 0)
+	for {
+		z, err := or.Read()
+		if err == io.EOF {
+			return or.Header(), orbit, nil
+		}
+		if err != nil {
+			return OrbitHeader{}, nil, err
+		}
+		orbit = append(orbit, z)
+	}
+}
+
+// SaveOrbit writes an orbit to the file at path
+func SaveOrbit(path string, header OrbitHeader, orbit []*ComplexBig) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteOrbit(file, header, orbit); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadOrbit reads an orbit from the file at path
+func LoadOrbit(path string) (OrbitHeader, []*ComplexBig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return OrbitHeader{}, nil, err
+	}
+	defer file.Close()
+	return ReadOrbit(file)
+}
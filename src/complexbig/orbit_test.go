@@ -0,0 +1,157 @@
+package complexbig
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+func testOrbit(ctx *Context, c *ComplexBig, n int) []*ComplexBig {
+	z := ctx.New(0, 0)
+	orbit := make([]*ComplexBig, 0, n)
+	for i := 0; i < n; i++ {
+		z.SqrAdd(z, c)
+		orbit = append(orbit, z.Copy())
+	}
+	return orbit
+}
+
+func TestOrbitRoundTrip(t *testing.T) {
+	ctx := NewContext(250)
+	c, _ := ctx.Parse("-0.7436438870371587+0.1318259042053119i")
+	orbit := testOrbit(ctx, c, 100)
+	// zeros and negative zeros are stored without a mantissa
+	orbit = append(orbit, ctx.New(0, 0), ctx.Neg(ctx.New(0, 0)))
+
+	path := filepath.Join(t.TempDir(), "orbit.bin")
+	header := OrbitHeader{Prec: ctx.Prec, Mode: ctx.Mode, C: c, MaxIt: 100}
+	if err := SaveOrbit(path, header, orbit); err != nil {
+		t.Fatal(err)
+	}
+
+	loadedHeader, loaded, err := LoadOrbit(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedHeader.Prec != 250 || loadedHeader.MaxIt != 100 || !loadedHeader.C.Equals(c) {
+		t.Fatalf("expected header %+v, got %+v", header, loadedHeader)
+	}
+	if len(loaded) != len(orbit) {
+		t.Fatalf("expected %v points, got %v", len(orbit), len(loaded))
+	}
+	for i := range orbit {
+		if !loaded[i].Equals(orbit[i]) || loaded[i].R.Prec() != 250 {
+			t.Fatalf("point %v: expected %v, got %v", i, orbit[i], loaded[i])
+		}
+		if loaded[i].I.Signbit() != orbit[i].I.Signbit() {
+			t.Fatalf("point %v: expected the sign of %v, got %v", i, orbit[i].I, loaded[i].I)
+		}
+	}
+}
+
+func TestOrbitIsCompact(t *testing.T) {
+	ctx := NewContext(256)
+	orbit := testOrbit(ctx, ctx.New(-1.2, 0.1), 50)
+
+	var buf bytes.Buffer
+	if err := WriteOrbit(&buf, OrbitHeader{Prec: 256, C: ctx.New(-1.2, 0.1)}, orbit); err != nil {
+		t.Fatal(err)
+	}
+	// a flags byte, a short exponent and 32 mantissa bytes per part
+	maxSize := 51 * 2 * (1 + 2 + 32)
+	if buf.Len() > maxSize {
+		t.Fatalf("expected at most %v bytes, got %v", maxSize, buf.Len())
+	}
+}
+
+func TestOrbitRoundsToHeaderPrecision(t *testing.T) {
+	z := NewContext(200).Div(NewContext(200).New(1, 0), NewContext(200).New(3, 0))
+
+	var buf bytes.Buffer
+	if err := WriteOrbit(&buf, OrbitHeader{Prec: 60, C: z}, []*ComplexBig{z}); err != nil {
+		t.Fatal(err)
+	}
+	_, orbit, err := ReadOrbit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := new(big.Float).SetPrec(60).Set(z.R); orbit[0].R.Cmp(expected) != 0 {
+		t.Fatalf("expected %v, got %v", expected, orbit[0].R)
+	}
+}
+
+func TestOrbitRoundsWithHeaderMode(t *testing.T) {
+	z := NewContext(200).Div(NewContext(200).New(1, 0), NewContext(200).New(3, 0))
+
+	for _, mode := range []big.RoundingMode{big.ToZero, big.AwayFromZero} {
+		var buf bytes.Buffer
+		if err := WriteOrbit(&buf, OrbitHeader{Prec: 60, Mode: mode, C: z}, []*ComplexBig{z}); err != nil {
+			t.Fatal(err)
+		}
+		_, orbit, err := ReadOrbit(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := new(big.Float).SetPrec(60).SetMode(mode).Set(z.R); orbit[0].R.Cmp(expected) != 0 {
+			t.Fatalf("%v: expected %v, got %v", mode, expected, orbit[0].R)
+		}
+	}
+}
+
+func TestOrbitReaderRejectsHugePrecision(t *testing.T) {
+	// a header that claims 2^40 bits per number
+	header := append([]byte("ORBT\x01"), 0x80, 0x80, 0x80, 0x80, 0x80, 0x20, 0, 0)
+	if _, err := NewOrbitReader(bytes.NewReader(header)); err == nil {
+		t.Fatalf("expected an error for a precision above MaxOrbitPrec")
+	}
+	if _, err := NewOrbitWriter(io.Discard, OrbitHeader{Prec: MaxOrbitPrec + 1, C: NewContext(53).New(0, 0)}); err == nil {
+		t.Fatalf("expected an error for a precision above MaxOrbitPrec")
+	}
+	if _, err := NewOrbitWriter(io.Discard, OrbitHeader{Prec: 53, Mode: 17, C: NewContext(53).New(0, 0)}); err == nil {
+		t.Fatalf("expected an error for an invalid rounding mode")
+	}
+}
+
+func TestOrbitReaderStreams(t *testing.T) {
+	ctx := NewContext(100)
+	orbit := testOrbit(ctx, ctx.New(0.3, 0.6), 20)
+
+	var buf bytes.Buffer
+	ow, err := NewOrbitWriter(&buf, OrbitHeader{Prec: 100, C: ctx.New(0.3, 0.6), MaxIt: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, z := range orbit {
+		if err := ow.Write(z); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ow.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	or, err := NewOrbitReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range orbit {
+		z, err := or.Read()
+		if err != nil || !z.Equals(orbit[i]) {
+			t.Fatalf("point %v: expected %v, got %v (%v)", i, orbit[i], z, err)
+		}
+	}
+	if _, err := or.Read(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	// a file that ends within a point is truncated
+	truncated := buf.Bytes()[:buf.Len()-3]
+	if _, _, err := ReadOrbit(bytes.NewReader(truncated)); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if _, _, err := ReadOrbit(bytes.NewReader([]byte("PNG\x00\x01"))); err == nil {
+		t.Fatalf("expected an error for a file that is not an orbit")
+	}
+}
@@ -0,0 +1,375 @@
+package complexbig
+
+import (
+	"math/big"
+	"sync"
+)
+
+// The real functions in this file compute their results with at least prec
+// bits of precision. They work with additional guard bits internally, so
+// that the result is accurate to the requested precision for all but
+// pathological arguments.
+
+const realGuardBits = 64
+
+// ErrNaN is the panic value of the functions whose result would be NaN, which
+// a big.Float cannot represent, like big.ErrNaN for the operations of
+// big.Float
+type ErrNaN struct {
+	msg string
+}
+
+func (err ErrNaN) Error() string {
+	return err.msg
+}
+
+func newFloat(prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec)
+}
+
+// negligible checks whether adding term to sum does not change sum at the
+// given precision anymore
+func negligible(term, sum *big.Float, prec uint) bool {
+	if term.Sign() == 0 {
+		return true
+	}
+	if sum.Sign() == 0 {
+		return false
+	}
+	return term.MantExp(nil) < sum.MantExp(nil)-int(prec)-1
+}
+
+var (
+	constMu  sync.Mutex
+	piCache  *big.Float
+	ln2Cache *big.Float
+)
+
+// pi returns π with precision prec, the constant is cached for the highest
+// precision that was requested so far
+func pi(prec uint) *big.Float {
+	constMu.Lock()
+	defer constMu.Unlock()
+	if piCache == nil || piCache.Prec() < prec {
+		// Machin's formula: π = 16 atan(1/5) - 4 atan(1/239)
+		wp := prec + realGuardBits
+		a := atanInv(5, wp)
+		a.Mul(a, big.NewFloat(16))
+		b := atanInv(239, wp)
+		b.Mul(b, big.NewFloat(4))
+		piCache = a.Sub(a, b)
+	}
+	return newFloat(prec).Set(piCache)
+}
+
+// ln2 returns log(2) with precision prec, the constant is cached like pi
+func ln2(prec uint) *big.Float {
+	constMu.Lock()
+	defer constMu.Unlock()
+	if ln2Cache == nil || ln2Cache.Prec() < prec {
+		// log(2) = 2 atanh(1/3) = 2 Σ 1 / ((2k+1) 3^(2k+1))
+		wp := prec + realGuardBits
+		sum := newFloat(wp)
+		power := newFloat(wp).Quo(big.NewFloat(1), big.NewFloat(3))
+		nine := big.NewFloat(9)
+		for k := int64(0); ; k++ {
+			term := newFloat(wp).Quo(power, newFloat(wp).SetInt64(2*k+1))
+			sum.Add(sum, term)
+			if negligible(term, sum, wp) {
+				break
+			}
+			power.Quo(power, nine)
+		}
+		ln2Cache = sum.Mul(sum, big.NewFloat(2))
+	}
+	return newFloat(prec).Set(ln2Cache)
+}
+
+// atanInv returns atan(1/x) for an integer x > 1
+func atanInv(x int64, prec uint) *big.Float {
+	// atan(1/x) = Σ (-1)^k / ((2k+1) x^(2k+1))
+	sum := newFloat(prec)
+	power := newFloat(prec).Quo(big.NewFloat(1), newFloat(prec).SetInt64(x))
+	x2 := newFloat(prec).SetInt64(x * x)
+	for k := int64(0); ; k++ {
+		term := newFloat(prec).Quo(power, newFloat(prec).SetInt64(2*k+1))
+		if k%2 == 0 {
+			sum.Add(sum, term)
+		} else {
+			sum.Sub(sum, term)
+		}
+		if negligible(term, sum, prec) {
+			return sum
+		}
+		power.Quo(power, x2)
+	}
+}
+
+// exp returns e^x
+func exp(x *big.Float, prec uint) *big.Float {
+	if x.Sign() == 0 {
+		return newFloat(prec).SetInt64(1)
+	}
+	if x.IsInf() {
+		if x.Sign() > 0 {
+			return newFloat(prec).SetInf(false)
+		}
+		return newFloat(prec)
+	}
+
+	wp := prec + realGuardBits
+	if e := x.MantExp(nil); e > 0 {
+		wp += uint(e)
+	}
+
+	// x = n*log(2) + r with |r| < log(2), so e^x = 2^n * e^r
+	l2 := ln2(wp)
+	n, _ := newFloat(wp).Quo(x, l2).Int64()
+	if n > 1<<30 {
+		return newFloat(prec).SetInf(false)
+	}
+	if n < -(1 << 30) {
+		return newFloat(prec)
+	}
+	r := newFloat(wp).Mul(newFloat(wp).SetInt64(n), l2)
+	r.Sub(x, r)
+
+	// e^r = (e^(r/2^k))^(2^k), the series converges much faster for the
+	// smaller argument
+	const k = 12
+	r.SetMantExp(r, -k)
+
+	sum := newFloat(wp).SetInt64(1)
+	term := newFloat(wp).SetInt64(1)
+	for i := int64(1); ; i++ {
+		term.Mul(term, r)
+		term.Quo(term, newFloat(wp).SetInt64(i))
+		sum.Add(sum, term)
+		if negligible(term, sum, wp) {
+			break
+		}
+	}
+	for i := 0; i < k; i++ {
+		sum.Mul(sum, sum)
+	}
+
+	sum.SetMantExp(sum, int(n))
+	return newFloat(prec).Set(sum)
+}
+
+// log returns the natural logarithm of x >= 0
+func log(x *big.Float, prec uint) *big.Float {
+	if x.Sign() == 0 {
+		return newFloat(prec).SetInf(true)
+	}
+	if x.IsInf() {
+		return newFloat(prec).SetInf(false)
+	}
+
+	wp := prec + realGuardBits
+
+	// x = m * 2^e with m in [1/sqrt(2), sqrt(2)), so log(x) = log(m) + e*log(2)
+	m := newFloat(wp)
+	e := x.MantExp(m)
+	if m.Cmp(big.NewFloat(0.7071067811865476)) < 0 {
+		m.SetMantExp(m, 1)
+		e--
+	}
+
+	// log(m) = 2 atanh(t) = 2 Σ t^(2k+1) / (2k+1) with t = (m-1)/(m+1)
+	one := big.NewFloat(1)
+	t := newFloat(wp).Sub(m, one)
+	t.Quo(t, newFloat(wp).Add(m, one))
+	t2 := newFloat(wp).Mul(t, t)
+
+	sum := newFloat(wp)
+	power := newFloat(wp).Set(t)
+	for k := int64(0); ; k++ {
+		term := newFloat(wp).Quo(power, newFloat(wp).SetInt64(2*k+1))
+		sum.Add(sum, term)
+		if negligible(term, sum, wp) {
+			break
+		}
+		power.Mul(power, t2)
+	}
+	sum.Mul(sum, big.NewFloat(2))
+
+	l := newFloat(wp).Mul(newFloat(wp).SetInt64(int64(e)), ln2(wp))
+	return newFloat(prec).Add(sum, l)
+}
+
+// sinCos returns sin(x) and cos(x), it panics with ErrNaN if x is infinite
+func sinCos(x *big.Float, prec uint) (sin, cos *big.Float) {
+	if x.IsInf() {
+		panic(ErrNaN{"sine and cosine of an infinite argument"})
+	}
+
+	wp := prec + realGuardBits
+	if e := x.MantExp(nil); e > 0 {
+		wp += uint(e)
+	}
+
+	// x = k*π/2 + r with |r| <= π/4
+	halfPi := pi(wp)
+	halfPi.SetMantExp(halfPi, -1)
+	q := newFloat(wp).Quo(x, halfPi)
+	q.Add(q, big.NewFloat(0.5))
+	k, acc := q.Int(nil)
+	if acc == big.Above {
+		// Int truncates towards zero, but k has to be rounded down
+		k.Sub(k, big.NewInt(1))
+	}
+	r := newFloat(wp).Mul(newFloat(wp).SetInt(k), halfPi)
+	r.Sub(x, r)
+
+	r2 := newFloat(wp).Mul(r, r)
+	s := newFloat(wp).Set(r)
+	c := newFloat(wp).SetInt64(1)
+
+	// sin(r) = Σ (-1)^i r^(2i+1) / (2i+1)!
+	term := newFloat(wp).Set(r)
+	for i := int64(1); ; i++ {
+		term.Mul(term, r2)
+		term.Quo(term, newFloat(wp).SetInt64(-(2*i)*(2*i+1)))
+		s.Add(s, term)
+		if negligible(term, s, wp) {
+			break
+		}
+	}
+
+	// cos(r) = Σ (-1)^i r^(2i) / (2i)!
+	term = newFloat(wp).SetInt64(1)
+	for i := int64(1); ; i++ {
+		term.Mul(term, r2)
+		term.Quo(term, newFloat(wp).SetInt64(-(2*i-1)*(2*i)))
+		c.Add(c, term)
+		if negligible(term, c, wp) {
+			break
+		}
+	}
+
+	switch new(big.Int).Mod(k, big.NewInt(4)).Int64() {
+	case 1:
+		s, c = c, s.Neg(s)
+	case 2:
+		s, c = s.Neg(s), c.Neg(c)
+	case 3:
+		s, c = c.Neg(c), s
+	}
+	return newFloat(prec).Set(s), newFloat(prec).Set(c)
+}
+
+// sinhCosh returns sinh(x) and cosh(x)
+func sinhCosh(x *big.Float, prec uint) (sinh, cosh *big.Float) {
+	wp := prec + realGuardBits
+
+	// e^x - e^-x cancels for small x, so the series is used instead
+	if x.MantExp(nil) <= 0 {
+		x2 := newFloat(wp).Mul(x, x)
+		s := newFloat(wp).Set(x)
+		c := newFloat(wp).SetInt64(1)
+
+		term := newFloat(wp).Set(x)
+		for i := int64(1); ; i++ {
+			term.Mul(term, x2)
+			term.Quo(term, newFloat(wp).SetInt64((2*i)*(2*i+1)))
+			s.Add(s, term)
+			if negligible(term, s, wp) {
+				break
+			}
+		}
+		term = newFloat(wp).SetInt64(1)
+		for i := int64(1); ; i++ {
+			term.Mul(term, x2)
+			term.Quo(term, newFloat(wp).SetInt64((2*i-1)*(2*i)))
+			c.Add(c, term)
+			if negligible(term, c, wp) {
+				break
+			}
+		}
+		return newFloat(prec).Set(s), newFloat(prec).Set(c)
+	}
+
+	e := exp(x, wp)
+	inv := newFloat(wp).Quo(big.NewFloat(1), e)
+
+	s := newFloat(wp).Sub(e, inv)
+	s.SetMantExp(s, -1)
+	c := newFloat(wp).Add(e, inv)
+	c.SetMantExp(c, -1)
+	return newFloat(prec).Set(s), newFloat(prec).Set(c)
+}
+
+// atan returns the arc tangent of x
+func atan(x *big.Float, prec uint) *big.Float {
+	wp := prec + realGuardBits
+	one := big.NewFloat(1)
+
+	// atan(t) = 2 atan(t / (1 + sqrt(1 + t^2))), halving the argument until
+	// the series converges quickly
+	t := newFloat(wp).Set(x)
+	k := 0
+	for t.Sign() != 0 && t.MantExp(nil) > -8 {
+		d := newFloat(wp).Mul(t, t)
+		d.Add(d, one)
+		d.Sqrt(d)
+		d.Add(d, one)
+		t.Quo(t, d)
+		k++
+	}
+
+	// atan(t) = Σ (-1)^i t^(2i+1) / (2i+1)
+	t2 := newFloat(wp).Mul(t, t)
+	sum := newFloat(wp).Set(t)
+	power := newFloat(wp).Set(t)
+	for i := int64(1); t.Sign() != 0; i++ {
+		power.Mul(power, t2)
+		term := newFloat(wp).Quo(power, newFloat(wp).SetInt64(2*i+1))
+		if i%2 == 1 {
+			sum.Sub(sum, term)
+		} else {
+			sum.Add(sum, term)
+		}
+		if negligible(term, sum, wp) {
+			break
+		}
+	}
+
+	sum.SetMantExp(sum, k)
+	return newFloat(prec).Set(sum)
+}
+
+// atan2 returns the angle of the point x, y in (-π, π], it follows the
+// conventions of math.Atan2 for signed zeros
+func atan2(y, x *big.Float, prec uint) *big.Float {
+	wp := prec + realGuardBits
+
+	if x.Sign() == 0 {
+		if y.Sign() == 0 {
+			if x.Signbit() {
+				p := pi(prec)
+				if y.Signbit() {
+					p.Neg(p)
+				}
+				return p
+			}
+			return newFloat(prec).Set(y)
+		}
+		halfPi := pi(prec)
+		halfPi.SetMantExp(halfPi, -1)
+		if y.Sign() < 0 {
+			halfPi.Neg(halfPi)
+		}
+		return halfPi
+	}
+
+	a := atan(newFloat(wp).Quo(y, x), wp)
+	if x.Sign() < 0 {
+		if y.Signbit() {
+			a.Sub(a, pi(wp))
+		} else {
+			a.Add(a, pi(wp))
+		}
+	}
+	return newFloat(prec).Set(a)
+}
@@ -0,0 +1,212 @@
+package complexbig
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// defaultParsePrec is the minimal precision of parsed numbers without an
+// explicit precision, it matches the default of big.Float
+const defaultParsePrec = 64
+
+// Text converts z to a string of the form "a+bi" or "a-bi". format and prec
+// are applied to both parts as in big.Float.Text, e.g. 'g' with prec -1 is
+// the shortest decimal that identifies the value at its precision and 'p'
+// or 'x' are exact hexadecimal floats.
+func (z *ComplexBig) Text(format byte, prec int) string {
+	r := z.R.Text(format, prec)
+	i := z.I.Text(format, prec)
+	if !strings.HasPrefix(i, "-") && !strings.HasPrefix(i, "+") {
+		i = "+" + i
+	}
+	return r + i + "i"
+}
+
+// Format implements fmt.Formatter. It accepts the formats of big.Float
+// except 'p', which fmt reserves for pointers, and 'v', which is 'g'. The
+// precision defaults to the shortest representation, flags and width are
+// ignored.
+func (z *ComplexBig) Format(s fmt.State, verb rune) {
+	prec, hasPrec := s.Precision()
+	if !hasPrec {
+		prec = -1
+	}
+	switch verb {
+	case 'e', 'E', 'f', 'b', 'x', 'X', 'g', 'G':
+	case 'v', 's':
+		verb = 'g'
+	default:
+		fmt.Fprintf(s, "%%!%c(*complexbig.ComplexBig=%s)", verb, z.String())
+		return
+	}
+	io.WriteString(s, z.Text(byte(verb), prec))
+}
+
+// Parse parses "a+bi", "a-bi", "bi", "a" or "(a,b)" where a and b are
+// numbers in any format that big.ParseFloat accepts with base 0, e.g.
+// "-1.5e-300" or "0x.8p+1". The imaginary coefficient may be omitted as in
+// "1-i". Both parts are rounded to prec bits, if prec is 0 the precision is
+// derived from the number of digits of the longer part, so no digit is lost,
+// but it is at least 64.
+func Parse(s string, prec uint) (*ComplexBig, error) {
+	return parse(s, prec, big.ToNearestEven)
+}
+
+// Parse is Parse with the precision and rounding mode of the context
+func (ctx *Context) Parse(s string) (*ComplexBig, error) {
+	return parse(s, ctx.Prec, ctx.Mode)
+}
+
+func parse(s string, prec uint, mode big.RoundingMode) (*ComplexBig, error) {
+	rText, iText, err := splitComplex(s)
+	if err != nil {
+		return nil, err
+	}
+	if prec == 0 {
+		prec = literalPrec(rText)
+		if p := literalPrec(iText); p > prec {
+			prec = p
+		}
+	}
+
+	r, _, err := big.ParseFloat(rText, 0, prec, mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid real part in %q: %v", s, err)
+	}
+	i, _, err := big.ParseFloat(iText, 0, prec, mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid imaginary part in %q: %v", s, err)
+	}
+	return &ComplexBig{R: r, I: i}, nil
+}
+
+// splitComplex splits s into the texts of its real and imaginary part
+func splitComplex(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", "", fmt.Errorf("cannot parse an empty complex number")
+	}
+
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		parts := strings.Split(s[1:len(s)-1], ",")
+		if len(parts) != 2 {
+			return "", "", fmt.Errorf("expected (a,b), got %q", s)
+		}
+		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+	}
+
+	if !strings.HasSuffix(s, "i") {
+		return s, "0", nil
+	}
+	body := strings.TrimSpace(s[:len(s)-1])
+
+	// the imaginary part starts at the last sign that is not part of an
+	// exponent
+	split := 0
+	for k := len(body) - 1; k > 0; k-- {
+		if body[k] != '+' && body[k] != '-' {
+			continue
+		}
+		prev := body[k-1]
+		if prev == 'p' || prev == 'P' {
+			continue
+		}
+		if (prev == 'e' || prev == 'E') && !endsWithHexMantissa(body[:k]) {
+			continue
+		}
+		split = k
+		break
+	}
+
+	r := strings.TrimSpace(body[:split])
+	i := strings.TrimSpace(body[split:])
+	if r == "" {
+		r = "0"
+	}
+	if strings.HasPrefix(i, "+") || strings.HasPrefix(i, "-") {
+		i = i[:1] + strings.TrimSpace(i[1:])
+	}
+	switch i {
+	case "", "+":
+		i = "1"
+	case "-":
+		i = "-1"
+	}
+	return r, i, nil
+}
+
+// endsWithHexMantissa checks whether s ends with a hexadecimal mantissa in
+// which e is a digit and not the start of an exponent
+func endsWithHexMantissa(s string) bool {
+	start := strings.LastIndex(strings.ToLower(s), "0x")
+	if start < 0 {
+		return false
+	}
+	for _, c := range s[start+2:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF._", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// literalPrec returns the number of bits needed to represent all digits of
+// the mantissa of the number literal s
+func literalPrec(s string) uint {
+	s = strings.TrimLeft(s, "+-")
+	lower := strings.ToLower(s)
+
+	// decimal mantissas may have a decimal or binary exponent
+	bitsPerDigit := math.Log2(10)
+	exponent := "ep"
+	switch {
+	case strings.HasPrefix(lower, "0x"):
+		bitsPerDigit, exponent, lower = 4, "p", lower[2:]
+	case strings.HasPrefix(lower, "0b"):
+		bitsPerDigit, exponent, lower = 1, "p", lower[2:]
+	case strings.HasPrefix(lower, "0o"):
+		bitsPerDigit, exponent, lower = 3, "p", lower[2:]
+	}
+	if end := strings.IndexAny(lower, exponent); end >= 0 {
+		lower = lower[:end]
+	}
+
+	digits := 0
+	for _, c := range strings.TrimLeft(lower, "0._") {
+		if c != '.' && c != '_' {
+			digits++
+		}
+	}
+
+	prec := uint(math.Ceil(float64(digits) * bitsPerDigit))
+	if prec < defaultParsePrec {
+		return defaultParsePrec
+	}
+	return prec
+}
+
+// MarshalText implements encoding.TextMarshaler. It writes the shortest
+// decimal "a+bi" that identifies the value at the precision of its parts.
+func (z *ComplexBig) MarshalText() ([]byte, error) {
+	return []byte(z.Text('g', -1)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler with Parse. If z already
+// has a precision it is kept, so unmarshaling into a number with the
+// precision it was marshaled with restores the exact value. Otherwise the
+// precision is derived from the text.
+func (z *ComplexBig) UnmarshalText(text []byte) error {
+	var prec uint
+	if z.R != nil && z.I != nil {
+		prec = ContextOf(z).Prec
+	}
+	parsed, err := Parse(string(text), prec)
+	if err != nil {
+		return err
+	}
+	z.R, z.I = parsed.R, parsed.I
+	return nil
+}
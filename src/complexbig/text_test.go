@@ -0,0 +1,165 @@
+package complexbig
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	z := &ComplexBig{R: big.NewFloat(1.5), I: big.NewFloat(-2)}
+	if z.String() != "1.5-2i" {
+		t.Fatalf("expected 1.5-2i, got %v", z.String())
+	}
+	z = &ComplexBig{R: big.NewFloat(-1.5), I: big.NewFloat(2)}
+	if z.String() != "-1.5+2i" {
+		t.Fatalf("expected -1.5+2i, got %v", z.String())
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		r, i float64
+	}{
+		{"1+2i", 1, 2},
+		{"1-2i", 1, -2},
+		{"-1.5-2.25i", -1.5, -2.25},
+		{" 3 + 4i ", 3, 4},
+		{"(1.5,-2)", 1.5, -2},
+		{"( -1e-3 , 2e+3 )", -1e-3, 2e3},
+		{"1e-3-2e+3i", 1e-3, -2e3},
+		{"2.5E+10+1E-10i", 2.5e10, 1e-10},
+		{"-2i", 0, -2},
+		{"1e-5i", 0, 1e-5},
+		{"7", 7, 0},
+		{"-7e2", -700, 0},
+		{"i", 0, 1},
+		{"1-i", 1, -1},
+		{"0x1.8p+1-0x.8p-1i", 3, -0.25},
+		{"0x1e-0x2i", 30, -2},
+		{"0x1ep-1+1i", 15, 1},
+	}
+	for _, test := range tests {
+		z, err := Parse(test.s, 53)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", test.s, err)
+		}
+		r, _ := z.R.Float64()
+		i, _ := z.I.Float64()
+		if r != test.r || i != test.i {
+			t.Fatalf("%q: expected %v%+vi, got %v", test.s, test.r, test.i, z)
+		}
+	}
+
+	for _, s := range []string{"", "1+2", "(1,2,3)", "a+bi", "1+2j", "1++2i"} {
+		if _, err := Parse(s, 53); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestParseDerivesPrecision(t *testing.T) {
+	z, err := Parse("0.1+0.2i", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.R.Prec() != 64 || z.I.Prec() != 64 {
+		t.Fatalf("expected 64 bits, got %v and %v", z.R.Prec(), z.I.Prec())
+	}
+
+	// 99 digits need 329 bits
+	digits := "1.23456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789"
+	z, err = Parse("(-"+digits+", 0)", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.R.Prec() != 329 || z.I.Prec() != 329 {
+		t.Fatalf("expected 329 bits, got %v and %v", z.R.Prec(), z.I.Prec())
+	}
+	if z.R.Text('g', 99) != "-"+digits {
+		t.Fatalf("expected -%v, got %v", digits, z.R.Text('g', 99))
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	ctx := NewContext(300)
+	z := ctx.Div(ctx.New(1, -2), ctx.New(3, 7))
+
+	for _, format := range []byte{'g', 'e', 'p', 'x', 'b'} {
+		text := z.Text(format, -1)
+		if format == 'p' || format == 'b' {
+			text = z.Text(format, 0)
+		}
+		parsed, err := ctx.Parse(text)
+		if err != nil {
+			t.Fatalf("%c: unexpected error %v", format, err)
+		}
+		if !parsed.Equals(z) {
+			t.Fatalf("%c: expected %v, got %v", format, z.Text('g', -1), parsed.Text('g', -1))
+		}
+	}
+
+	// hexadecimal floats are exact without knowing the precision
+	parsed, err := Parse(z.Text('p', 0), 0)
+	if err != nil || !parsed.Equals(z) {
+		t.Fatalf("expected %v, got %v (%v)", z.Text('p', 0), parsed, err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	z := &ComplexBig{R: big.NewFloat(0.125), I: big.NewFloat(-3)}
+	tests := map[string]string{
+		"%v":   "0.125-3i",
+		"%.2e": "1.25e-01-3.00e+00i",
+		"%.1f": "0.1-3.0i",
+		"%x":   "0x1p-03-0x1.8p+01i",
+		"%d":   "%!d(*complexbig.ComplexBig=0.125-3i)",
+	}
+	for format, expected := range tests {
+		if actual := fmt.Sprintf(format, z); actual != expected {
+			t.Fatalf("%v: expected %v, got %v", format, expected, actual)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type checkpoint struct {
+		Center *ComplexBig
+	}
+	ctx := NewContext(200)
+	center := ctx.Div(ctx.New(-3, 1), ctx.New(7, 0))
+
+	data, err := json.Marshal(checkpoint{Center: center})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// decoding into a number with the original precision restores it exactly
+	decoded := checkpoint{Center: ctx.New(0, 0)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Center.Equals(center) || decoded.Center.R.Prec() != 200 {
+		t.Fatalf("expected %v, got %v", center.Text('g', -1), decoded.Center.Text('g', -1))
+	}
+
+	// without a precision it is derived from the digits
+	var derived checkpoint
+	if err := json.Unmarshal(data, &derived); err != nil {
+		t.Fatal(err)
+	}
+	if derived.Center.R.Prec() < 200 {
+		t.Fatalf("expected at least 200 bits, got %v", derived.Center.R.Prec())
+	}
+	diff := ctx.Sub(derived.Center, center)
+	tolerance := new(big.Float).SetMantExp(big.NewFloat(1), -200)
+	if new(big.Float).Abs(diff.R).Cmp(tolerance) > 0 || new(big.Float).Abs(diff.I).Cmp(tolerance) > 0 {
+		t.Fatalf("expected %v, got %v", center.Text('g', -1), derived.Center.Text('g', -1))
+	}
+
+	if err := json.Unmarshal([]byte(`{"Center": "1+"}`), &derived); err == nil {
+		t.Fatalf("expected an error for an invalid number")
+	}
+}
@@ -0,0 +1,119 @@
+package complexdd
+
+import (
+	"moritz/go-fractals/src/complexbig"
+)
+
+// ComplexDD are complex numbers represented by double-doubles. They are
+// values, so none of the operations allocate.
+type ComplexDD struct {
+	R DD
+	I DD
+}
+
+// ComplexDDFromBig rounds a to double-double precision
+func ComplexDDFromBig(a *complexbig.ComplexBig) ComplexDD {
+	return ComplexDD{R: DDFromBig(a.R), I: DDFromBig(a.I)}
+}
+
+// Big converts a to a ComplexBig with the given precision
+func (a ComplexDD) Big(prec uint) *complexbig.ComplexBig {
+	return &complexbig.ComplexBig{R: a.R.Big(prec), I: a.I.Big(prec)}
+}
+
+// Add a+b=z
+func (a ComplexDD) Add(b ComplexDD) ComplexDD {
+	return ComplexDD{R: a.R.Add(b.R), I: a.I.Add(b.I)}
+}
+
+// Mul a*b=z
+func (a ComplexDD) Mul(b ComplexDD) ComplexDD {
+	return ComplexDD{
+		R: a.R.Mul(b.R).Sub(a.I.Mul(b.I)),
+		I: a.I.Mul(b.R).Add(a.R.Mul(b.I)),
+	}
+}
+
+// Sqr a*a=z
+func (a ComplexDD) Sqr() ComplexDD {
+	return ComplexDD{
+		R: a.R.Sqr().Sub(a.I.Sqr()),
+		I: a.R.Mul(a.I).MulPow2(2),
+	}
+}
+
+// AbsSq returns |a|^2
+func (a ComplexDD) AbsSq() DD {
+	return a.R.Sqr().Add(a.I.Sqr())
+}
+
+// Equals checks two numbers for equality
+func (a ComplexDD) Equals(b ComplexDD) bool {
+	return a == b
+}
+
+// MirrorImaginary returns the complex conjugate of a
+func (a ComplexDD) MirrorImaginary() ComplexDD {
+	return ComplexDD{R: a.R, I: a.I.Neg()}
+}
+
+func (a ComplexDD) String() string {
+	return a.Big(106).String()
+}
+
+// ComplexQD are complex numbers represented by quad-doubles. They are
+// values, so none of the operations allocate.
+type ComplexQD struct {
+	R QD
+	I QD
+}
+
+// ComplexQDFromBig rounds a to quad-double precision
+func ComplexQDFromBig(a *complexbig.ComplexBig) ComplexQD {
+	return ComplexQD{R: QDFromBig(a.R), I: QDFromBig(a.I)}
+}
+
+// Big converts a to a ComplexBig with the given precision
+func (a ComplexQD) Big(prec uint) *complexbig.ComplexBig {
+	return &complexbig.ComplexBig{R: a.R.Big(prec), I: a.I.Big(prec)}
+}
+
+// Add a+b=z
+func (a ComplexQD) Add(b ComplexQD) ComplexQD {
+	return ComplexQD{R: a.R.Add(b.R), I: a.I.Add(b.I)}
+}
+
+// Mul a*b=z
+func (a ComplexQD) Mul(b ComplexQD) ComplexQD {
+	return ComplexQD{
+		R: a.R.Mul(b.R).Sub(a.I.Mul(b.I)),
+		I: a.I.Mul(b.R).Add(a.R.Mul(b.I)),
+	}
+}
+
+// Sqr a*a=z
+func (a ComplexQD) Sqr() ComplexQD {
+	return ComplexQD{
+		R: a.R.Sqr().Sub(a.I.Sqr()),
+		I: a.R.Mul(a.I).MulPow2(2),
+	}
+}
+
+// AbsSq returns |a|^2
+func (a ComplexQD) AbsSq() QD {
+	return a.R.Sqr().Add(a.I.Sqr())
+}
+
+// Equals checks two numbers for equality
+func (a ComplexQD) Equals(b ComplexQD) bool {
+	return a == b
+}
+
+// MirrorImaginary returns the complex conjugate of a
+func (a ComplexQD) MirrorImaginary() ComplexQD {
+	return ComplexQD{R: a.R, I: a.I.Neg()}
+}
+
+func (a ComplexQD) String() string {
+	return a.Big(212).String()
+}
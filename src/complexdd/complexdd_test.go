@@ -0,0 +1,94 @@
+package complexdd
+
+import (
+	"math/big"
+	"math/rand"
+	"moritz/go-fractals/src/complexbig"
+	"testing"
+)
+
+// randomBig returns a random number in [-2, 2) with prec significant bits
+func randomBig(rnd *rand.Rand, prec uint) *big.Float {
+	x := new(big.Float).SetPrec(prec)
+	for i := uint(0); i < prec; i += 50 {
+		part := new(big.Float).SetFloat64(rnd.Float64()*4 - 2)
+		part.SetMantExp(part, -int(i))
+		x.Add(x, part)
+	}
+	return x
+}
+
+func randomComplex(rnd *rand.Rand, prec uint) *complexbig.ComplexBig {
+	return &complexbig.ComplexBig{R: randomBig(rnd, prec), I: randomBig(rnd, prec)}
+}
+
+// expectClose checks that a and b differ by at most 2^-bits relative to the
+// magnitude of the operands, which are in [-2, 2)
+func expectClose(t *testing.T, name string, a, b *complexbig.ComplexBig, bits int) {
+	t.Helper()
+	tolerance := new(big.Float).SetMantExp(big.NewFloat(1), -bits)
+	dr := new(big.Float).Sub(a.R, b.R)
+	di := new(big.Float).Sub(a.I, b.I)
+	if dr.Abs(dr).Cmp(tolerance) > 0 || di.Abs(di).Cmp(tolerance) > 0 {
+		t.Fatalf("%v: expected %v, got %v", name, a.R.Text('g', 70), b.R.Text('g', 70))
+	}
+}
+
+func TestComplexDD(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	ctx := complexbig.NewContext(400)
+
+	for i := 0; i < 1000; i++ {
+		a := randomComplex(rnd, 200)
+		b := randomComplex(rnd, 200)
+		ad, bd := ComplexDDFromBig(a), ComplexDDFromBig(b)
+
+		// compare to the exact operations on the rounded operands
+		a, b = ad.Big(400), bd.Big(400)
+
+		expectClose(t, "round trip", ComplexDDFromBig(a).Big(400), a, 150)
+		expectClose(t, "Add", ctx.Add(a, b), ad.Add(bd).Big(400), 102)
+		expectClose(t, "Mul", ctx.Mul(a, b), ad.Mul(bd).Big(400), 100)
+		expectClose(t, "Sqr", ctx.Mul(a, a), ad.Sqr().Big(400), 100)
+		expectClose(t, "MirrorImaginary", ctx.MirrorImaginary(a), ad.MirrorImaginary().Big(400), 150)
+
+		absSq := ctx.Mul(a, ctx.MirrorImaginary(a))
+		expectClose(t, "AbsSq", absSq, ComplexDD{R: ad.AbsSq()}.Big(400), 100)
+	}
+}
+
+func TestComplexQD(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	ctx := complexbig.NewContext(600)
+
+	for i := 0; i < 1000; i++ {
+		a := randomComplex(rnd, 400)
+		b := randomComplex(rnd, 400)
+		aq, bq := ComplexQDFromBig(a), ComplexQDFromBig(b)
+
+		a, b = aq.Big(600), bq.Big(600)
+
+		expectClose(t, "round trip", ComplexQDFromBig(a).Big(600), a, 300)
+		expectClose(t, "Add", ctx.Add(a, b), aq.Add(bq).Big(600), 205)
+		expectClose(t, "Mul", ctx.Mul(a, b), aq.Mul(bq).Big(600), 200)
+		expectClose(t, "Sqr", ctx.Mul(a, a), aq.Sqr().Big(600), 200)
+		expectClose(t, "MirrorImaginary", ctx.MirrorImaginary(a), aq.MirrorImaginary().Big(600), 300)
+
+		absSq := ctx.Mul(a, ctx.MirrorImaginary(a))
+		expectClose(t, "AbsSq", absSq, ComplexQD{R: aq.AbsSq()}.Big(600), 200)
+	}
+}
+
+func TestCmp(t *testing.T) {
+	one := NewDD(1)
+	oneAndABit := DD{Hi: 1, Lo: 1e-20}
+	if one.Cmp(oneAndABit) != -1 || oneAndABit.Cmp(one) != 1 || one.Cmp(one) != 0 {
+		t.Fatalf("unexpected double-double comparison")
+	}
+
+	q := NewQD(1)
+	r := QD{1, 0, 1e-40}
+	if q.Cmp(r) != -1 || r.Cmp(q) != 1 || q.Cmp(q) != 0 {
+		t.Fatalf("unexpected quad-double comparison")
+	}
+}
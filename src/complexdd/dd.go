@@ -0,0 +1,112 @@
+package complexdd
+
+import (
+	"math"
+	"math/big"
+)
+
+// DD is a double-double number, the unevaluated sum Hi + Lo with |Lo| at most
+// half an ulp of Hi. It has about 106 bits of precision and the exponent
+// range of float64.
+type DD struct {
+	Hi, Lo float64
+}
+
+// DDPrec is the precision of double-doubles in bits
+const DDPrec = 106
+
+// twoSum returns s = fl(a+b) and the rounding error e, so that a+b = s+e
+func twoSum(a, b float64) (s, e float64) {
+	s = a + b
+	bb := s - a
+	e = (a - (s - bb)) + (b - bb)
+	return s, e
+}
+
+// quickTwoSum is twoSum for |a| >= |b|
+func quickTwoSum(a, b float64) (s, e float64) {
+	s = a + b
+	e = b - (s - a)
+	return s, e
+}
+
+// twoProd returns p = fl(a*b) and the rounding error e, so that a*b = p+e
+func twoProd(a, b float64) (p, e float64) {
+	p = a * b
+	e = math.FMA(a, b, -p)
+	return p, e
+}
+
+// NewDD converts a float64 to a double-double
+func NewDD(f float64) DD {
+	return DD{Hi: f}
+}
+
+// DDFromBig rounds x to a double-double
+func DDFromBig(x *big.Float) DD {
+	hi, _ := x.Float64()
+	rest := new(big.Float).SetPrec(x.Prec()).Sub(x, big.NewFloat(hi))
+	lo, _ := rest.Float64()
+	hi, lo = quickTwoSum(hi, lo)
+	return DD{Hi: hi, Lo: lo}
+}
+
+// Big converts a to a big.Float with the given precision
+func (a DD) Big(prec uint) *big.Float {
+	z := new(big.Float).SetPrec(prec).SetFloat64(a.Hi)
+	return z.Add(z, big.NewFloat(a.Lo))
+}
+
+// Float64 rounds a to float64
+func (a DD) Float64() float64 {
+	return a.Hi + a.Lo
+}
+
+func (a DD) Add(b DD) DD {
+	s, e := twoSum(a.Hi, b.Hi)
+	t, f := twoSum(a.Lo, b.Lo)
+	e += t
+	s, e = quickTwoSum(s, e)
+	e += f
+	s, e = quickTwoSum(s, e)
+	return DD{Hi: s, Lo: e}
+}
+
+func (a DD) Neg() DD {
+	return DD{Hi: -a.Hi, Lo: -a.Lo}
+}
+
+func (a DD) Sub(b DD) DD {
+	return a.Add(b.Neg())
+}
+
+func (a DD) Mul(b DD) DD {
+	p, e := twoProd(a.Hi, b.Hi)
+	e += a.Hi*b.Lo + a.Lo*b.Hi
+	p, e = quickTwoSum(p, e)
+	return DD{Hi: p, Lo: e}
+}
+
+func (a DD) Sqr() DD {
+	p, e := twoProd(a.Hi, a.Hi)
+	e += 2 * a.Hi * a.Lo
+	e += a.Lo * a.Lo
+	p, e = quickTwoSum(p, e)
+	return DD{Hi: p, Lo: e}
+}
+
+// MulPow2 multiplies a by a power of two f, which is exact
+func (a DD) MulPow2(f float64) DD {
+	return DD{Hi: a.Hi * f, Lo: a.Lo * f}
+}
+
+// Cmp compares a and b and returns -1, 0 or +1
+func (a DD) Cmp(b DD) int {
+	switch {
+	case a.Hi < b.Hi || (a.Hi == b.Hi && a.Lo < b.Lo):
+		return -1
+	case a.Hi > b.Hi || (a.Hi == b.Hi && a.Lo > b.Lo):
+		return 1
+	}
+	return 0
+}
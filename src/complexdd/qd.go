@@ -0,0 +1,187 @@
+package complexdd
+
+import (
+	"math"
+	"math/big"
+)
+
+// QD is a quad-double number, the unevaluated sum of four float64 that do
+// not overlap. It has about 212 bits of precision and the exponent range of
+// float64. The algorithms follow the QD library by Hida, Li and Bailey.
+type QD [4]float64
+
+// QDPrec is the precision of quad-doubles in bits
+const QDPrec = 212
+
+// threeSum adds a, b and c, so that a+b+c = x+y+z with x the sum
+func threeSum(a, b, c float64) (x, y, z float64) {
+	t1, t2 := twoSum(a, b)
+	x, t3 := twoSum(c, t1)
+	y, z = twoSum(t2, t3)
+	return x, y, z
+}
+
+// threeSum2 is threeSum without the smallest error term
+func threeSum2(a, b, c float64) (x, y float64) {
+	t1, t2 := twoSum(a, b)
+	x, t3 := twoSum(c, t1)
+	return x, t2 + t3
+}
+
+// renorm normalizes the five components into four non-overlapping ones
+func renorm(c0, c1, c2, c3, c4 float64) QD {
+	if math.IsInf(c0, 0) {
+		return QD{c0, c1, c2, c3}
+	}
+
+	s0, c4 := quickTwoSum(c3, c4)
+	s0, c3 = quickTwoSum(c2, s0)
+	s0, c2 = quickTwoSum(c1, s0)
+	c0, c1 = quickTwoSum(c0, s0)
+
+	s0 = c0
+	s1 := c1
+	var s2, s3 float64
+
+	if s1 != 0 {
+		s1, s2 = quickTwoSum(s1, c2)
+		if s2 != 0 {
+			s2, s3 = quickTwoSum(s2, c3)
+			if s3 != 0 {
+				s3 += c4
+			} else {
+				s2, s3 = quickTwoSum(s2, c4)
+			}
+		} else {
+			s1, s2 = quickTwoSum(s1, c3)
+			if s2 != 0 {
+				s2, s3 = quickTwoSum(s2, c4)
+			} else {
+				s1, s2 = quickTwoSum(s1, c4)
+			}
+		}
+	} else {
+		s0, s1 = quickTwoSum(s0, c2)
+		if s1 != 0 {
+			s1, s2 = quickTwoSum(s1, c3)
+			if s2 != 0 {
+				s2, s3 = quickTwoSum(s2, c4)
+			} else {
+				s1, s2 = quickTwoSum(s1, c4)
+			}
+		} else {
+			s0, s1 = quickTwoSum(s0, c3)
+			if s1 != 0 {
+				s1, s2 = quickTwoSum(s1, c4)
+			} else {
+				s0, s1 = quickTwoSum(s0, c4)
+			}
+		}
+	}
+	return QD{s0, s1, s2, s3}
+}
+
+// NewQD converts a float64 to a quad-double
+func NewQD(f float64) QD {
+	return QD{f}
+}
+
+// QDFromBig rounds x to a quad-double
+func QDFromBig(x *big.Float) QD {
+	var q QD
+	rest := new(big.Float).SetPrec(x.Prec()).Set(x)
+	for i := range q {
+		q[i], _ = rest.Float64()
+		rest.Sub(rest, big.NewFloat(q[i]))
+	}
+	return renorm(q[0], q[1], q[2], q[3], 0)
+}
+
+// Big converts a to a big.Float with the given precision
+func (a QD) Big(prec uint) *big.Float {
+	// the components are summed exactly and rounded once
+	sum := new(big.Float).SetPrec(prec + 4*53).SetFloat64(a[3])
+	for i := 2; i >= 0; i-- {
+		sum.Add(sum, big.NewFloat(a[i]))
+	}
+	return new(big.Float).SetPrec(prec).Set(sum)
+}
+
+// Float64 rounds a to float64
+func (a QD) Float64() float64 {
+	return a[0] + a[1] + a[2] + a[3]
+}
+
+func (a QD) Add(b QD) QD {
+	s0, t0 := twoSum(a[0], b[0])
+	s1, t1 := twoSum(a[1], b[1])
+	s2, t2 := twoSum(a[2], b[2])
+	s3, t3 := twoSum(a[3], b[3])
+
+	s1, t0 = twoSum(s1, t0)
+	s2, t0, t1 = threeSum(s2, t0, t1)
+	s3, t0 = threeSum2(s3, t0, t2)
+	t0 = t0 + t1 + t3
+
+	return renorm(s0, s1, s2, s3, t0)
+}
+
+func (a QD) Neg() QD {
+	return QD{-a[0], -a[1], -a[2], -a[3]}
+}
+
+func (a QD) Sub(b QD) QD {
+	return a.Add(b.Neg())
+}
+
+func (a QD) Mul(b QD) QD {
+	p0, q0 := twoProd(a[0], b[0])
+
+	p1, q1 := twoProd(a[0], b[1])
+	p2, q2 := twoProd(a[1], b[0])
+
+	p3, q3 := twoProd(a[0], b[2])
+	p4, q4 := twoProd(a[1], b[1])
+	p5, q5 := twoProd(a[2], b[0])
+
+	// start accumulation
+	p1, p2, q0 = threeSum(p1, p2, q0)
+
+	// six-three sum of p2, q1, q2, p3, p4, p5
+	p2, q1, q2 = threeSum(p2, q1, q2)
+	p3, p4, p5 = threeSum(p3, p4, p5)
+
+	// (s0, s1, s2) = (p2, q1, q2) + (p3, p4, p5)
+	s0, t0 := twoSum(p2, p3)
+	s1, t1 := twoSum(q1, p4)
+	s2 := q2 + p5
+	s1, t0 = twoSum(s1, t0)
+	s2 += t0 + t1
+
+	// O(eps^3) order terms
+	s1 += a[0]*b[3] + a[1]*b[2] + a[2]*b[1] + a[3]*b[0] + q0 + q3 + q4 + q5
+
+	return renorm(p0, p1, s0, s1, s2)
+}
+
+func (a QD) Sqr() QD {
+	return a.Mul(a)
+}
+
+// MulPow2 multiplies a by a power of two f, which is exact
+func (a QD) MulPow2(f float64) QD {
+	return QD{a[0] * f, a[1] * f, a[2] * f, a[3] * f}
+}
+
+// Cmp compares a and b and returns -1, 0 or +1
+func (a QD) Cmp(b QD) int {
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"math/big"
+	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/complexdd"
+)
+
+// Arithmetic implements the operations that the iteration and the
+// buddhabrot pipeline need for complex numbers of type T, so that the number
+// type can be swapped. Operations that take a destination may reuse it for
+// the result, callers always have to use the returned value.
+type Arithmetic[T any] interface {
+	// Zero returns 0+0i
+	Zero() T
+	// FromFloat64 returns r+i*i
+	FromFloat64(r, i float64) T
+	// FromBig rounds c to the precision of the arithmetic
+	FromBig(c *complexbig.ComplexBig) T
+	// Float64 returns the real and imaginary part of z rounded to float64
+	Float64(z T) (float64, float64)
+	// SqrAdd returns z*z+c, the result may be stored in z
+	SqrAdd(z, c T) T
+	// Set returns a copy of x, the result may be stored in z
+	Set(z, x T) T
+	// Copy returns a copy of z that does not share memory with z
+	Copy(z T) T
+	Equals(a, b T) bool
+	// Diverged checks whether |z| > 2
+	Diverged(z T) bool
+	// MirrorImaginary returns the complex conjugate of z
+	MirrorImaginary(z T) T
+}
+
+// Float64Arithmetic is the Arithmetic of complex128
+type Float64Arithmetic struct{}
+
+func (Float64Arithmetic) Zero() complex128                    { return 0 }
+func (Float64Arithmetic) FromFloat64(r, i float64) complex128 { return complex(r, i) }
+
+func (Float64Arithmetic) FromBig(c *complexbig.ComplexBig) complex128 {
+	r, _ := c.R.Float64()
+	i, _ := c.I.Float64()
+	return complex(r, i)
+}
+
+func (Float64Arithmetic) Float64(z complex128) (float64, float64) { return real(z), imag(z) }
+func (Float64Arithmetic) SqrAdd(z, c complex128) complex128       { return z*z + c }
+func (Float64Arithmetic) Set(z, x complex128) complex128          { return x }
+func (Float64Arithmetic) Copy(z complex128) complex128            { return z }
+func (Float64Arithmetic) Equals(a, b complex128) bool             { return a == b }
+
+func (Float64Arithmetic) Diverged(z complex128) bool {
+	return real(z)*real(z)+imag(z)*imag(z) > 4
+}
+
+func (Float64Arithmetic) MirrorImaginary(z complex128) complex128 {
+	return complex(real(z), -imag(z))
+}
+
+// DDArithmetic is the Arithmetic of double-doubles
+type DDArithmetic struct{}
+
+var ddFour = complexdd.NewDD(4)
+
+func (DDArithmetic) Zero() complexdd.ComplexDD { return complexdd.ComplexDD{} }
+
+func (DDArithmetic) FromFloat64(r, i float64) complexdd.ComplexDD {
+	return complexdd.ComplexDD{R: complexdd.NewDD(r), I: complexdd.NewDD(i)}
+}
+
+func (DDArithmetic) FromBig(c *complexbig.ComplexBig) complexdd.ComplexDD {
+	return complexdd.ComplexDDFromBig(c)
+}
+
+func (DDArithmetic) Float64(z complexdd.ComplexDD) (float64, float64) {
+	return z.R.Float64(), z.I.Float64()
+}
+
+func (DDArithmetic) SqrAdd(z, c complexdd.ComplexDD) complexdd.ComplexDD { return z.Sqr().Add(c) }
+func (DDArithmetic) Set(z, x complexdd.ComplexDD) complexdd.ComplexDD    { return x }
+func (DDArithmetic) Copy(z complexdd.ComplexDD) complexdd.ComplexDD      { return z }
+func (DDArithmetic) Equals(a, b complexdd.ComplexDD) bool                { return a.Equals(b) }
+func (DDArithmetic) Diverged(z complexdd.ComplexDD) bool                 { return z.AbsSq().Cmp(ddFour) == 1 }
+
+func (DDArithmetic) MirrorImaginary(z complexdd.ComplexDD) complexdd.ComplexDD {
+	return z.MirrorImaginary()
+}
+
+// QDArithmetic is the Arithmetic of quad-doubles
+type QDArithmetic struct{}
+
+var qdFour = complexdd.NewQD(4)
+
+func (QDArithmetic) Zero() complexdd.ComplexQD { return complexdd.ComplexQD{} }
+
+func (QDArithmetic) FromFloat64(r, i float64) complexdd.ComplexQD {
+	return complexdd.ComplexQD{R: complexdd.NewQD(r), I: complexdd.NewQD(i)}
+}
+
+func (QDArithmetic) FromBig(c *complexbig.ComplexBig) complexdd.ComplexQD {
+	return complexdd.ComplexQDFromBig(c)
+}
+
+func (QDArithmetic) Float64(z complexdd.ComplexQD) (float64, float64) {
+	return z.R.Float64(), z.I.Float64()
+}
+
+func (QDArithmetic) SqrAdd(z, c complexdd.ComplexQD) complexdd.ComplexQD { return z.Sqr().Add(c) }
+func (QDArithmetic) Set(z, x complexdd.ComplexQD) complexdd.ComplexQD    { return x }
+func (QDArithmetic) Copy(z complexdd.ComplexQD) complexdd.ComplexQD      { return z }
+func (QDArithmetic) Equals(a, b complexdd.ComplexQD) bool                { return a.Equals(b) }
+func (QDArithmetic) Diverged(z complexdd.ComplexQD) bool                 { return z.AbsSq().Cmp(qdFour) == 1 }
+
+func (QDArithmetic) MirrorImaginary(z complexdd.ComplexQD) complexdd.ComplexQD {
+	return z.MirrorImaginary()
+}
+
+// BigArithmetic is the Arithmetic of big.Float with the precision and
+// rounding mode of Ctx. SqrAdd and Set work in place and do not allocate.
+type BigArithmetic struct {
+	Ctx *complexbig.Context
+}
+
+var bigFour = big.NewFloat(4)
+
+func (a BigArithmetic) Zero() *complexbig.ComplexBig { return a.Ctx.New(0, 0) }
+
+func (a BigArithmetic) FromFloat64(r, i float64) *complexbig.ComplexBig {
+	return a.Ctx.New(r, i)
+}
+
+func (a BigArithmetic) FromBig(c *complexbig.ComplexBig) *complexbig.ComplexBig {
+	return a.Ctx.Set(c)
+}
+
+func (BigArithmetic) Float64(z *complexbig.ComplexBig) (float64, float64) {
+	r, _ := z.R.Float64()
+	i, _ := z.I.Float64()
+	return r, i
+}
+
+func (BigArithmetic) SqrAdd(z, c *complexbig.ComplexBig) *complexbig.ComplexBig {
+	return z.SqrAdd(z, c)
+}
+
+func (BigArithmetic) Set(z, x *complexbig.ComplexBig) *complexbig.ComplexBig {
+	return z.Set(x)
+}
+
+func (BigArithmetic) Copy(z *complexbig.ComplexBig) *complexbig.ComplexBig { return z.Copy() }
+func (BigArithmetic) Equals(a, b *complexbig.ComplexBig) bool              { return a.Equals(b) }
+
+func (BigArithmetic) Diverged(z *complexbig.ComplexBig) bool {
+	return z.AbsSq().Cmp(bigFour) == 1
+}
+
+func (a BigArithmetic) MirrorImaginary(z *complexbig.ComplexBig) *complexbig.ComplexBig {
+	return a.Ctx.MirrorImaginary(z)
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"moritz/go-fractals/src/complexdd"
+)
+
+// Backend is the number type that is used for the iteration
+type Backend int
+
+const (
+	Float64 Backend = iota
+	DoubleDouble
+	QuadDouble
+	BigFloat
+)
+
+var backendNames = map[Backend]string{
+	Float64:      "float64",
+	DoubleDouble: "double-double",
+	QuadDouble:   "quad-double",
+	BigFloat:     "big.Float",
+}
+
+func (b Backend) String() string {
+	if name, ok := backendNames[b]; ok {
+		return name
+	}
+	return fmt.Sprintf("Backend(%d)", int(b))
+}
+
+// ParseBackend returns the backend with the given name
+func ParseBackend(name string) (Backend, error) {
+	for b, n := range backendNames {
+		if n == name {
+			return b, nil
+		}
+	}
+	return BigFloat, fmt.Errorf("unknown backend %q", name)
+}
+
+// BackendForPrecision returns the fastest backend that provides at least
+// prec bits of precision: float64 up to 53 bits, double-doubles up to 106
+// bits and quad-doubles up to 212 bits, beyond that only big.Float is precise
+// enough. With the precision of complexbig.PrecisionForPixelSize an image
+// that is 1000 pixels high uses float64 up to a zoom of about 3e10,
+// double-doubles up to about 3e26 and quad-doubles up to about 2e58.
+func BackendForPrecision(prec uint) Backend {
+	switch {
+	case prec <= 53:
+		return Float64
+	case prec <= complexdd.DDPrec:
+		return DoubleDouble
+	case prec <= complexdd.QDPrec:
+		return QuadDouble
+	}
+	return BigFloat
+}
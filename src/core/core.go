@@ -3,29 +3,78 @@ package core
 import (
 	"math/big"
 	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/complexdd"
 )
 
+// Iterate iterates z = z*z + c with the highest precision of c's parts, see
+// IterateContext
 func Iterate(c *complexbig.ComplexBig, maxIt int) ([]*complexbig.ComplexBig, bool) {
-	z := &complexbig.ComplexBig{R: big.NewFloat(0), I: big.NewFloat(0)}
-	oldZ := &complexbig.ComplexBig{R: big.NewFloat(0), I: big.NewFloat(0)}
+	return IterateContext(complexbig.ContextOf(c), c, maxIt)
+}
+
+// IterateContext iterates z = z*z + c for at most maxIt iterations with at
+// least the precision of ctx. It returns the trajectory if the series
+// diverges and whether c is in the set.
+// The iteration runs on the fastest backend that is precise enough, see
+// BackendForPrecision. The faster backends always round to nearest, so
+// other rounding modes are only honored by big.Float.
+func IterateContext(ctx *complexbig.Context, c *complexbig.ComplexBig, maxIt int) ([]*complexbig.ComplexBig, bool) {
+	backend := BigFloat
+	if ctx.Mode == big.ToNearestEven {
+		backend = BackendForPrecision(ctx.Prec)
+	}
+
+	switch backend {
+	case Float64:
+		r, _ := c.R.Float64()
+		i, _ := c.I.Float64()
+		trajectory, inSet := IterateFloat64(complex(r, i), maxIt)
+		result := make([]*complexbig.ComplexBig, len(trajectory))
+		for j, z := range trajectory {
+			result[j] = ctx.New(real(z), imag(z))
+		}
+		return result, inSet
+	case DoubleDouble:
+		trajectory, inSet := IterateDD(complexdd.ComplexDDFromBig(c), maxIt)
+		result := make([]*complexbig.ComplexBig, len(trajectory))
+		for j, z := range trajectory {
+			result[j] = z.Big(ctx.Prec)
+		}
+		return result, inSet
+	case QuadDouble:
+		trajectory, inSet := IterateQD(complexdd.ComplexQDFromBig(c), maxIt)
+		result := make([]*complexbig.ComplexBig, len(trajectory))
+		for j, z := range trajectory {
+			result[j] = z.Big(ctx.Prec)
+		}
+		return result, inSet
+	}
+	return IterateBig(ctx, c, maxIt)
+}
 
-	previous := make([]*complexbig.ComplexBig, 0, maxIt)
+// IterateWith iterates z = z*z + c for at most maxIt iterations with the
+// number type of the given arithmetic. It returns the trajectory if the
+// series diverges and whether c is in the set.
+func IterateWith[T any, A Arithmetic[T]](a A, c T, maxIt int) ([]T, bool) {
+	z := a.Zero()
+	oldZ := a.Zero()
+
+	previous := make([]T, 0, maxIt)
 
 	stepsTaken := 0
 	stepLimit := 2
 
 	for i := 0; i < maxIt; i++ {
 		// z = z*z + c
-		z = complexbig.Mul(z, z)
-		z.Add(c)
+		z = a.SqrAdd(z, c)
 
 		// brents cycle detection
-		if z.Equals(oldZ) {
+		if a.Equals(z, oldZ) {
 			return nil, true
 		}
 
 		if stepsTaken == stepLimit {
-			oldZ = z
+			oldZ = a.Set(oldZ, z)
 			stepsTaken = 0
 			stepLimit *= 2
 		}
@@ -33,12 +82,34 @@ func Iterate(c *complexbig.ComplexBig, maxIt int) ([]*complexbig.ComplexBig, boo
 		stepsTaken++
 
 		// if |z| > 2 -> series diverges
-		if z.Abs().Cmp(complexbig.Two) == 1 {
+		if a.Diverged(z) {
 			return previous, false
 		}
-		previous = append(previous, z)
+		previous = append(previous, a.Copy(z))
 	}
 
 	// series did not diverge after maxIt iterations
 	return nil, true
 }
+
+// IterateBig is IterateContext with big.Float, all values are computed with
+// the precision and rounding mode of ctx. Apart from the copies of z that
+// make up the trajectory the iteration does not allocate.
+func IterateBig(ctx *complexbig.Context, c *complexbig.ComplexBig, maxIt int) ([]*complexbig.ComplexBig, bool) {
+	return IterateWith(BigArithmetic{Ctx: ctx}, ctx.Set(c), maxIt)
+}
+
+// IterateFloat64 is IterateContext with complex128
+func IterateFloat64(c complex128, maxIt int) ([]complex128, bool) {
+	return IterateWith(Float64Arithmetic{}, c, maxIt)
+}
+
+// IterateDD is IterateContext with double-doubles
+func IterateDD(c complexdd.ComplexDD, maxIt int) ([]complexdd.ComplexDD, bool) {
+	return IterateWith(DDArithmetic{}, c, maxIt)
+}
+
+// IterateQD is IterateContext with quad-doubles
+func IterateQD(c complexdd.ComplexQD, maxIt int) ([]complexdd.ComplexQD, bool) {
+	return IterateWith(QDArithmetic{}, c, maxIt)
+}
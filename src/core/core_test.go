@@ -0,0 +1,194 @@
+package core
+
+import (
+	"math/big"
+	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/complexdd"
+	"testing"
+)
+
+// iterateAllocating is the previous implementation of IterateBig that
+// allocates new numbers in every iteration, it is kept as a reference
+func iterateAllocating(ctx *complexbig.Context, c *complexbig.ComplexBig, maxIt int) ([]*complexbig.ComplexBig, bool) {
+	c = ctx.Set(c)
+	z := ctx.New(0, 0)
+	oldZ := ctx.New(0, 0)
+
+	previous := make([]*complexbig.ComplexBig, 0, maxIt)
+
+	stepsTaken := 0
+	stepLimit := 2
+
+	for i := 0; i < maxIt; i++ {
+		z = ctx.Add(ctx.Mul(z, z), c)
+
+		if z.Equals(oldZ) {
+			return nil, true
+		}
+
+		if stepsTaken == stepLimit {
+			oldZ = z
+			stepsTaken = 0
+			stepLimit *= 2
+		}
+
+		stepsTaken++
+
+		if ctx.Abs(z).Cmp(complexbig.Two) == 1 {
+			return previous, false
+		}
+		previous = append(previous, z)
+	}
+
+	return nil, true
+}
+
+var points = []*complexbig.ComplexBig{
+	{R: big.NewFloat(-0.75), I: big.NewFloat(0.1)},
+	{R: big.NewFloat(0.3), I: big.NewFloat(0.5)},
+	{R: big.NewFloat(-1.3), I: big.NewFloat(0.06)},
+	{R: big.NewFloat(-0.1), I: big.NewFloat(0.2)},
+	{R: big.NewFloat(1), I: big.NewFloat(1)},
+}
+
+func TestIterateMatchesReference(t *testing.T) {
+	ctx := complexbig.NewContext(100)
+	for _, c := range points {
+		expected, expectedInSet := iterateAllocating(ctx, c, 500)
+		actual, inSet := IterateBig(ctx, c, 500)
+
+		if inSet != expectedInSet {
+			t.Fatalf("%v: expected in set %v, got %v", c, expectedInSet, inSet)
+		}
+		if len(actual) != len(expected) {
+			t.Fatalf("%v: expected %v points, got %v", c, len(expected), len(actual))
+		}
+		for i := range actual {
+			if !actual[i].Equals(expected[i]) {
+				t.Fatalf("%v: point %v expected %v, got %v", c, i, expected[i], actual[i])
+			}
+		}
+	}
+}
+
+func TestIterateTrajectoryIsCopied(t *testing.T) {
+	trajectory, inSet := Iterate(&complexbig.ComplexBig{R: big.NewFloat(0.3), I: big.NewFloat(0.6)}, 100)
+	if inSet {
+		t.Fatalf("expected 0.3+0.6i to diverge")
+	}
+	if trajectory[0].Equals(trajectory[1]) {
+		t.Fatalf("expected distinct trajectory points")
+	}
+}
+
+func benchmarkIterate(b *testing.B, iterate func(*complexbig.Context, *complexbig.ComplexBig, int) ([]*complexbig.ComplexBig, bool)) {
+	ctx := complexbig.NewContext(100)
+	for i := 0; i < b.N; i++ {
+		for _, c := range points {
+			iterate(ctx, c, 200)
+		}
+	}
+}
+
+func TestBackendsMatchBigFloat(t *testing.T) {
+	for _, prec := range []uint{53, 100, 200} {
+		ctx := complexbig.NewContext(prec)
+		tolerance := new(big.Float).SetMantExp(big.NewFloat(1), -int(prec)+20)
+
+		for _, c := range points {
+			expected, expectedInSet := IterateBig(ctx, c, 100)
+			actual, inSet := IterateContext(ctx, c, 100)
+
+			if inSet != expectedInSet || len(actual) != len(expected) {
+				t.Fatalf("%v bits, %v: expected %v points, got %v", prec, c, len(expected), len(actual))
+			}
+			// rounding errors grow during the iteration, so only the first
+			// points are compared closely
+			for i := 0; i < len(actual) && i < 10; i++ {
+				diff := ctx.Sub(actual[i], expected[i])
+				if new(big.Float).Abs(diff.R).Cmp(tolerance) > 0 || new(big.Float).Abs(diff.I).Cmp(tolerance) > 0 {
+					t.Fatalf("%v bits, %v: point %v expected %v, got %v", prec, c, i, expected[i], actual[i])
+				}
+			}
+		}
+	}
+}
+
+func TestBackendForPrecision(t *testing.T) {
+	expected := map[uint]Backend{53: Float64, 54: DoubleDouble, 106: DoubleDouble, 107: QuadDouble, 212: QuadDouble, 213: BigFloat}
+	for prec, backend := range expected {
+		if BackendForPrecision(prec) != backend {
+			t.Fatalf("%v bits: expected %v, got %v", prec, backend, BackendForPrecision(prec))
+		}
+	}
+}
+
+func BenchmarkIterate(b *testing.B) {
+	benchmarkIterate(b, IterateBig)
+}
+
+func BenchmarkIterateAllocating(b *testing.B) {
+	benchmarkIterate(b, iterateAllocating)
+}
+
+func BenchmarkIterateFloat64(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, c := range points {
+			r, _ := c.R.Float64()
+			im, _ := c.I.Float64()
+			IterateFloat64(complex(r, im), 200)
+		}
+	}
+}
+
+func BenchmarkIterateDD(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, c := range points {
+			IterateDD(complexdd.ComplexDDFromBig(c), 200)
+		}
+	}
+}
+
+func BenchmarkIterateQD(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, c := range points {
+			IterateQD(complexdd.ComplexQDFromBig(c), 200)
+		}
+	}
+}
+
+func benchmarkIterateWith[T any, A Arithmetic[T]](b *testing.B, a A) {
+	cs := make([]T, len(points))
+	for j, c := range points {
+		cs[j] = a.FromBig(c)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, c := range cs {
+			IterateWith(a, c, 200)
+		}
+	}
+}
+
+// BenchmarkIterateWith compares the backends side by side, the big.Float
+// backend uses the precision of the double-double backend
+func BenchmarkIterateWith(b *testing.B) {
+	b.Run(Float64.String(), func(b *testing.B) { benchmarkIterateWith[complex128](b, Float64Arithmetic{}) })
+	b.Run(DoubleDouble.String(), func(b *testing.B) { benchmarkIterateWith[complexdd.ComplexDD](b, DDArithmetic{}) })
+	b.Run(QuadDouble.String(), func(b *testing.B) { benchmarkIterateWith[complexdd.ComplexQD](b, QDArithmetic{}) })
+	b.Run(BigFloat.String(), func(b *testing.B) {
+		benchmarkIterateWith[*complexbig.ComplexBig](b, BigArithmetic{Ctx: complexbig.NewContext(complexdd.DDPrec)})
+	})
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, backend := range []Backend{Float64, DoubleDouble, QuadDouble, BigFloat} {
+		parsed, err := ParseBackend(backend.String())
+		if err != nil || parsed != backend {
+			t.Fatalf("expected %v, got %v (%v)", backend, parsed, err)
+		}
+	}
+	if _, err := ParseBackend("float32"); err == nil {
+		t.Fatalf("expected an error for an unknown backend")
+	}
+}
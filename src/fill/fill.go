@@ -0,0 +1,217 @@
+package fill
+
+import "fmt"
+
+// Strategy selects how the values of a rectangular area are computed
+type Strategy int
+
+const (
+	// BruteForce computes every value of the area
+	BruteForce Strategy = iota
+	// MarianiSilver computes the border of a rectangle first and fills the
+	// rectangle if the whole border has the same value, otherwise the
+	// rectangle is split in two and both halves are processed recursively
+	MarianiSilver
+	// BoundaryTrace follows the borders between areas of different values
+	// starting at the edges and fills the areas enclosed by them without
+	// computing their inner values
+	BoundaryTrace
+)
+
+var strategyNames = map[Strategy]string{
+	BruteForce:    "brute",
+	MarianiSilver: "mariani",
+	BoundaryTrace: "boundary",
+}
+
+func (s Strategy) String() string {
+	if name, ok := strategyNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Strategy(%d)", int(s))
+}
+
+// ParseStrategy returns the strategy with the given name
+func ParseStrategy(name string) (Strategy, error) {
+	for s, n := range strategyNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return BruteForce, fmt.Errorf("unknown fill strategy %q", name)
+}
+
+// unknown marks values that have not been computed yet, compute functions
+// must therefore only return non-negative values
+const unknown = -1
+
+type area struct {
+	width, height int
+	values        []int
+	compute       func(x, y int) int
+}
+
+// Area computes the values of a width x height area with the given strategy.
+// compute is called with coordinates relative to the area and has to return
+// a non-negative value, e.g. an escape count. The values are returned row by
+// row.
+// MarianiSilver and BoundaryTrace assume that there are no islands, i.e. no
+// region of equal values that is enclosed by another region without touching
+// the edge of the area. Islands are filled with the surrounding value. The
+// Mandelbrot set has islands at every resolution, minibrots and filaments
+// that are thinner than the distance between points are only hit by isolated
+// points, so both strategies are only suited for images where a few wrong
+// values do not matter.
+func Area(strategy Strategy, width, height int, compute func(x, y int) int) []int {
+	a := &area{
+		width:   width,
+		height:  height,
+		values:  make([]int, width*height),
+		compute: compute,
+	}
+	for i := range a.values {
+		a.values[i] = unknown
+	}
+	if width == 0 || height == 0 {
+		return a.values
+	}
+
+	switch strategy {
+	case MarianiSilver:
+		a.subdivide(0, 0, width-1, height-1)
+	case BoundaryTrace:
+		a.trace()
+	default:
+		a.computeAll(0, 0, width-1, height-1)
+	}
+	return a.values
+}
+
+// at returns the value at x, y and computes it if necessary
+func (a *area) at(x, y int) int {
+	idx := y*a.width + x
+	if a.values[idx] == unknown {
+		a.values[idx] = a.compute(x, y)
+	}
+	return a.values[idx]
+}
+
+// computeAll computes every value in the rectangle x0, y0 to x1, y1
+// (inclusive)
+func (a *area) computeAll(x0, y0, x1, y1 int) {
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			a.at(x, y)
+		}
+	}
+}
+
+// subdivide applies the Mariani-Silver algorithm to the rectangle x0, y0 to
+// x1, y1 (inclusive)
+func (a *area) subdivide(x0, y0, x1, y1 int) {
+	// rectangles without inner points are computed completely
+	if x1-x0 < 2 || y1-y0 < 2 {
+		a.computeAll(x0, y0, x1, y1)
+		return
+	}
+
+	// the whole border is computed even if it is not uniform, so that the
+	// values can be reused by the subrectangles
+	v := a.at(x0, y0)
+	uniform := true
+	for x := x0; x <= x1; x++ {
+		if a.at(x, y0) != v || a.at(x, y1) != v {
+			uniform = false
+		}
+	}
+	for y := y0 + 1; y < y1; y++ {
+		if a.at(x0, y) != v || a.at(x1, y) != v {
+			uniform = false
+		}
+	}
+
+	if uniform {
+		for y := y0 + 1; y < y1; y++ {
+			for x := x0 + 1; x < x1; x++ {
+				a.values[y*a.width+x] = v
+			}
+		}
+		return
+	}
+
+	// split along the longer side, both halves share the middle line
+	if x1-x0 >= y1-y0 {
+		mid := (x0 + x1) / 2
+		a.subdivide(x0, y0, mid, y1)
+		a.subdivide(mid, y0, x1, y1)
+	} else {
+		mid := (y0 + y1) / 2
+		a.subdivide(x0, y0, x1, mid)
+		a.subdivide(x0, mid, x1, y1)
+	}
+}
+
+// trace applies boundary tracing to the whole area. Starting at the edges,
+// every point that has a neighbour with a different value is at a boundary,
+// so its neighbours are explored as well. Afterwards every point that was not
+// reached lies inside an area enclosed by a boundary and takes the value of
+// its left neighbour.
+func (a *area) trace() {
+	queued := make([]bool, len(a.values))
+	queue := make([]int, 0, 2*(a.width+a.height))
+
+	push := func(x, y int) {
+		if x < 0 || y < 0 || x >= a.width || y >= a.height {
+			return
+		}
+		idx := y*a.width + x
+		if !queued[idx] {
+			queued[idx] = true
+			queue = append(queue, idx)
+		}
+	}
+	pushNeighbours := func(x, y int) {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				push(x+dx, y+dy)
+			}
+		}
+	}
+
+	for x := 0; x < a.width; x++ {
+		push(x, 0)
+		push(x, a.height-1)
+	}
+	for y := 0; y < a.height; y++ {
+		push(0, y)
+		push(a.width-1, y)
+	}
+
+	for head := 0; head < len(queue); head++ {
+		x, y := queue[head]%a.width, queue[head]/a.width
+		v := a.at(x, y)
+
+		neighbours := [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}}
+		for _, n := range neighbours {
+			nx, ny := n[0], n[1]
+			if nx < 0 || ny < 0 || nx >= a.width || ny >= a.height {
+				continue
+			}
+			nv := a.values[ny*a.width+nx]
+			if nv != unknown && nv != v {
+				pushNeighbours(x, y)
+				pushNeighbours(nx, ny)
+			}
+		}
+	}
+
+	// the left edge was traced completely, so every left neighbour is known
+	for y := 0; y < a.height; y++ {
+		for x := 1; x < a.width; x++ {
+			idx := y*a.width + x
+			if a.values[idx] == unknown {
+				a.values[idx] = a.values[idx-1]
+			}
+		}
+	}
+}
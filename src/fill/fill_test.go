@@ -0,0 +1,103 @@
+package fill
+
+import (
+	"math"
+	"testing"
+)
+
+// rings assigns each point the index of the ring around -20, -10 it lies in,
+// the center is outside of the area so that no ring is enclosed by another one
+func rings(x, y int) int {
+	dx, dy := float64(x+20), float64(y+10)
+	return int(math.Sqrt(dx*dx+dy*dy) / 8)
+}
+
+func countingRings(calls *int) func(x, y int) int {
+	return func(x, y int) int {
+		*calls++
+		return rings(x, y)
+	}
+}
+
+func TestStrategiesMatchBruteForce(t *testing.T) {
+	width, height := 90, 70
+	bruteCalls := 0
+	expected := Area(BruteForce, width, height, countingRings(&bruteCalls))
+
+	if bruteCalls != width*height {
+		t.Fatalf("expected %v calls, got %v", width*height, bruteCalls)
+	}
+
+	for _, strategy := range []Strategy{MarianiSilver, BoundaryTrace} {
+		calls := 0
+		actual := Area(strategy, width, height, countingRings(&calls))
+
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Fatalf("%v: value %v,%v expected %v, got %v",
+					strategy, i%width, i/width, expected[i], actual[i])
+			}
+		}
+		if calls >= bruteCalls {
+			t.Fatalf("%v: expected less than %v calls, got %v", strategy, bruteCalls, calls)
+		}
+	}
+}
+
+// inSet returns 1 for points of a 60 x 60 grid over -1.9..-1.6 x
+// -0.15..0.15 that are in the Mandelbrot set. The minibrot of period 5
+// around -1.6254 is connected to the rest of the set by filaments much
+// thinner than the distance between the points.
+func inSet(x, y int) int {
+	c := complex(-1.9+float64(x)*0.3/59, -0.15+float64(y)*0.3/59)
+	z := c
+	for i := 0; i < 1000; i++ {
+		if real(z)*real(z)+imag(z)*imag(z) > 4 {
+			return 0
+		}
+		z = z*z + c
+	}
+	return 1
+}
+
+func TestStrategiesMissMinibrot(t *testing.T) {
+	expected := Area(BruteForce, 60, 60, inSet)
+	// the points closest to the minibrot
+	minibrot := [][2]int{{54, 29}, {54, 30}}
+	for _, p := range minibrot {
+		if expected[p[1]*60+p[0]] != 1 {
+			t.Fatalf("expected %v to be in the minibrot", p)
+		}
+	}
+
+	for _, strategy := range []Strategy{MarianiSilver, BoundaryTrace} {
+		actual := Area(strategy, 60, 60, inSet)
+		for i := range actual {
+			// islands are filled with the surrounding value
+			if actual[i] == 1 && expected[i] != 1 {
+				t.Fatalf("%v: expected %v,%v to be outside of the set", strategy, i%60, i/60)
+			}
+		}
+		for _, p := range minibrot {
+			if actual[p[1]*60+p[0]] != 0 {
+				t.Fatalf("%v: expected the island at %v to be filled", strategy, p)
+			}
+		}
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, strategy := range []Strategy{BruteForce, MarianiSilver, BoundaryTrace} {
+		parsed, err := ParseStrategy(strategy.String())
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if parsed != strategy {
+			t.Fatalf("expected %v, got %v", strategy, parsed)
+		}
+	}
+
+	if _, err := ParseStrategy("unknown"); err == nil {
+		t.Fatalf("expected error for unknown strategy")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/gif"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyframe is a point of a camera path, see README.md for the file format
+type keyframe struct {
+	// CenterX and CenterY are decimal strings so that they can be more
+	// precise than float64
+	CenterX       string  `json:"centerX"`
+	CenterY       string  `json:"centerY"`
+	Zoom          decimal `json:"zoom"`
+	Rotation      float64 `json:"rotation"`
+	MaxIt         int     `json:"maxIt"`
+	PaletteOffset float64 `json:"paletteOffset"`
+	// Frames is the number of frames of the transition to the next keyframe
+	Frames int `json:"frames"`
+	// Easing is the easing curve of the transition to the next keyframe
+	Easing string `json:"easing"`
+
+	view viewport
+	ease func(t float64) float64
+}
+
+// decimal is a number that can be given as JSON number or as string, so
+// that it is not limited to float64
+type decimal string
+
+func (d *decimal) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = decimal(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*d = decimal(n)
+	return nil
+}
+
+type animation struct {
+	Keyframes []keyframe `json:"keyframes"`
+}
+
+var easings = map[string]func(t float64) float64{
+	"":       func(t float64) float64 { return t },
+	"linear": func(t float64) float64 { return t },
+	"easeIn": func(t float64) float64 { return t * t * t },
+	"easeOut": func(t float64) float64 {
+		return 1 - math.Pow(1-t, 3)
+	},
+	"easeInOut": func(t float64) float64 {
+		if t < 0.5 {
+			return 4 * t * t * t
+		}
+		return 1 - math.Pow(-2*t+2, 3)/2
+	},
+}
+
+// loadAnimation reads and validates a keyframe file
+func loadAnimation(path string) (*animation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	anim := &animation{}
+	if err := json.Unmarshal(data, anim); err != nil {
+		return nil, err
+	}
+	if len(anim.Keyframes) < 2 {
+		return nil, fmt.Errorf("an animation needs at least 2 keyframes")
+	}
+
+	for i := range anim.Keyframes {
+		k := &anim.Keyframes[i]
+
+		x, err := parseDecimal(k.CenterX)
+		if err != nil {
+			return nil, fmt.Errorf("keyframe %v: centerX: %v", i, err)
+		}
+		y, err := parseDecimal(k.CenterY)
+		if err != nil {
+			return nil, fmt.Errorf("keyframe %v: centerY: %v", i, err)
+		}
+		// image coordinates grow downwards
+		y.Neg(y)
+
+		zoom, err := parseDecimal(string(k.Zoom))
+		if err != nil {
+			return nil, fmt.Errorf("keyframe %v: zoom: %v", i, err)
+		}
+		if zoom.Sign() <= 0 {
+			return nil, fmt.Errorf("keyframe %v: zoom has to be positive", i)
+		}
+		if k.MaxIt <= 0 {
+			return nil, fmt.Errorf("keyframe %v: maxIt has to be positive", i)
+		}
+		if i < len(anim.Keyframes)-1 && k.Frames < 1 {
+			return nil, fmt.Errorf("keyframe %v: frames has to be positive", i)
+		}
+
+		ease, ok := easings[k.Easing]
+		if !ok {
+			return nil, fmt.Errorf("keyframe %v: unknown easing %q", i, k.Easing)
+		}
+
+		k.view = viewport{centerX: x, centerY: y, zoom: zoom, rotation: k.Rotation}
+		k.ease = ease
+	}
+	return anim, nil
+}
+
+// nFrames returns the total number of frames including the last keyframe
+func (a *animation) nFrames() int {
+	n := 1
+	for _, k := range a.Keyframes[:len(a.Keyframes)-1] {
+		n += k.Frames
+	}
+	return n
+}
+
+// apply configures c for the given frame of the animation
+func (a *animation) apply(c *config, frame int) {
+	last := len(a.Keyframes) - 1
+	from, to, t := a.Keyframes[last], a.Keyframes[last], 1.0
+
+	for i := 0; i < last; i++ {
+		if frame < a.Keyframes[i].Frames {
+			from, to = a.Keyframes[i], a.Keyframes[i+1]
+			t = from.ease(float64(frame) / float64(from.Frames))
+			break
+		}
+		frame -= a.Keyframes[i].Frames
+	}
+
+	c.setViewport(interpolateViewport(from.view, to.view, t))
+	c.maxIt = from.MaxIt + int(math.Round(float64(to.MaxIt-from.MaxIt)*t))
+	c.paletteOffset = from.PaletteOffset + (to.PaletteOffset-from.PaletteOffset)*t
+}
+
+// runAnimation renders every frame of a keyframe file as a numbered png and
+// combines them into an animated gif
+func runAnimation(args []string) {
+	path := ""
+	out := "animation"
+	delay := 4
+
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		argArr := strings.Split(strings.Replace(arg, "--", "", 1), "=")
+		switch argArr[0] {
+		case "keyframes":
+			path = argArr[1]
+		case "out":
+			out = argArr[1]
+		case "delay":
+			delay, _ = strconv.Atoi(argArr[1])
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	a, err := loadAnimation(path)
+	if err != nil {
+		panic(err)
+	}
+	conf = createConfig(rest)
+
+	if err := os.MkdirAll(out, 0755); err != nil {
+		panic(err)
+	}
+
+	anim := &gif.GIF{}
+	n := a.nFrames()
+	go printProgress(2*time.Second, n*conf.width*conf.height)
+	for frame := 0; frame < n; frame++ {
+		a.apply(conf, frame)
+		renderFrame(frame, out, anim, delay)
+		fmt.Printf("frame %v/%v, zoom %.3g, maxIt %v\n", frame+1, n, conf.view.zoom, conf.maxIt)
+	}
+	setDone()
+
+	saveGIF(filepath.Join(out, "animation.gif"), anim)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKeyframes(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "keyframes.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("could not write keyframes: %v", err)
+	}
+	return path
+}
+
+func TestLoadAnimation(t *testing.T) {
+	path := writeKeyframes(t, `{"keyframes": [
+		{"centerX": "0", "centerY": "0", "zoom": 1, "maxIt": 100, "frames": 4, "easing": "easeInOut"},
+		{"centerX": "-0.7436438870371587048786667767891", "centerY": "0.131825904205311970493132056385",
+			"zoom": 100, "maxIt": 300, "paletteOffset": 0.5, "frames": 2},
+		{"centerX": "-0.7436438870371587048786667767891", "centerY": "0.131825904205311970493132056385",
+			"zoom": "1e4", "maxIt": 500}
+	]}`)
+
+	a, err := loadAnimation(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if a.nFrames() != 7 {
+		t.Fatalf("expected 7 frames, got %v", a.nFrames())
+	}
+
+	// the center keeps all digits of the string
+	x, _, _ := big.ParseFloat("-0.7436438870371587048786667767891", 10, 200, big.ToNearestEven)
+	if a.Keyframes[1].view.centerX.Text('g', 31) != x.Text('g', 31) {
+		t.Fatalf("expected %v, got %v", x.Text('g', 31), a.Keyframes[1].view.centerX.Text('g', 31))
+	}
+
+	c := createConfig([]string{"--width=30", "--height=20"})
+
+	a.apply(c, 0)
+	if c.view.zoom.Cmp(big.NewFloat(1)) != 0 || c.maxIt != 100 || c.paletteOffset != 0 {
+		t.Fatalf("expected first keyframe, got zoom %v maxIt %v", c.view.zoom, c.maxIt)
+	}
+
+	// easeInOut is exactly half way in the middle of the transition
+	a.apply(c, 2)
+	if c.maxIt != 200 || c.paletteOffset != 0.25 {
+		t.Fatalf("expected maxIt 200 and offset 0.25, got %v %v", c.maxIt, c.paletteOffset)
+	}
+
+	a.apply(c, 4)
+	if c.view.zoom.Cmp(big.NewFloat(100)) != 0 || c.maxIt != 300 || c.view.centerX.Cmp(a.Keyframes[1].view.centerX) != 0 {
+		t.Fatalf("expected second keyframe, got zoom %v maxIt %v", c.view.zoom, c.maxIt)
+	}
+
+	a.apply(c, 6)
+	if c.view.zoom.Cmp(big.NewFloat(10000)) != 0 || c.maxIt != 500 {
+		t.Fatalf("expected last keyframe, got zoom %v maxIt %v", c.view.zoom, c.maxIt)
+	}
+}
+
+func TestLoadAnimationErrors(t *testing.T) {
+	invalid := []string{
+		`{"keyframes": [{"centerX": "0", "centerY": "0", "zoom": 1, "maxIt": 100}]}`,
+		`{"keyframes": [
+			{"centerX": "0", "centerY": "0", "zoom": 1, "maxIt": 100, "frames": 2, "easing": "bounce"},
+			{"centerX": "0", "centerY": "0", "zoom": 2, "maxIt": 100}]}`,
+		`{"keyframes": [
+			{"centerX": "zero", "centerY": "0", "zoom": 1, "maxIt": 100, "frames": 2},
+			{"centerX": "0", "centerY": "0", "zoom": 2, "maxIt": 100}]}`,
+		`{"keyframes": [
+			{"centerX": "0", "centerY": "0", "zoom": 1, "maxIt": 100, "frames": 0},
+			{"centerX": "0", "centerY": "0", "zoom": 2, "maxIt": 100}]}`,
+	}
+
+	for _, content := range invalid {
+		if _, err := loadAnimation(writeKeyframes(t, content)); err == nil {
+			t.Fatalf("expected error for %v", content)
+		}
+	}
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"math/big"
+	"moritz/go-fractals/src/complexdd"
+	"moritz/go-fractals/src/utils/metrics"
 )
 
 var two *big.Float = big.NewFloat(2)
-var skipped int
+
+// skipped counts the points that were found to be in the set by cycle
+// detection
+var skipped *metrics.Counter = metrics.NewCounter()
 
 type complexBig struct {
 	r *big.Float
@@ -42,8 +47,10 @@ func (a *complexBig) abs() (z *big.Float) {
 	return r.Sqrt(r)
 }
 
+// diverges iterates c with its own precision, the results of mul and add
+// take the precision of their operands
 func diverges(c *complexBig) (bool, int) {
-	z := &complexBig{big.NewFloat(0), big.NewFloat(0)}
+	z := &complexBig{new(big.Float).SetPrec(c.r.Prec()), new(big.Float).SetPrec(c.i.Prec())}
 	previous := make([]*complexBig, 0, conf.maxIt)
 
 	for i := 0; i < conf.maxIt; i++ {
@@ -51,7 +58,7 @@ func diverges(c *complexBig) (bool, int) {
 		if conf.skip {
 			for _, p := range previous {
 				if p.equals(z) {
-					skipped++
+					skipped.Inc()
 					return false, i
 				}
 			}
@@ -70,3 +77,77 @@ func diverges(c *complexBig) (bool, int) {
 	}
 	return false, conf.maxIt
 }
+
+// divergesFloat64 is diverges for coordinates that fit into a float64
+func divergesFloat64(c complex128) (bool, int) {
+	z := complex(0, 0)
+	previous := make([]complex128, 0, conf.maxIt)
+
+	for i := 0; i < conf.maxIt; i++ {
+		if conf.skip {
+			for _, p := range previous {
+				if p == z {
+					skipped.Inc()
+					return false, i
+				}
+			}
+			previous = append(previous, z)
+		}
+		z = z*z + c
+
+		if real(z)*real(z)+imag(z)*imag(z) > 4 {
+			return true, i
+		}
+	}
+	return false, conf.maxIt
+}
+
+// divergesDD is diverges with double-double precision
+func divergesDD(c complexdd.ComplexDD) (bool, int) {
+	var z complexdd.ComplexDD
+	four := complexdd.NewDD(4)
+	previous := make([]complexdd.ComplexDD, 0, conf.maxIt)
+
+	for i := 0; i < conf.maxIt; i++ {
+		if conf.skip {
+			for _, p := range previous {
+				if p.Equals(z) {
+					skipped.Inc()
+					return false, i
+				}
+			}
+			previous = append(previous, z)
+		}
+		z = z.Sqr().Add(c)
+
+		if z.AbsSq().Cmp(four) == 1 {
+			return true, i
+		}
+	}
+	return false, conf.maxIt
+}
+
+// divergesQD is diverges with quad-double precision
+func divergesQD(c complexdd.ComplexQD) (bool, int) {
+	var z complexdd.ComplexQD
+	four := complexdd.NewQD(4)
+	previous := make([]complexdd.ComplexQD, 0, conf.maxIt)
+
+	for i := 0; i < conf.maxIt; i++ {
+		if conf.skip {
+			for _, p := range previous {
+				if p.Equals(z) {
+					skipped.Inc()
+					return false, i
+				}
+			}
+			previous = append(previous, z)
+		}
+		z = z.Sqr().Add(c)
+
+		if z.AbsSq().Cmp(four) == 1 {
+			return true, i
+		}
+	}
+	return false, conf.maxIt
+}
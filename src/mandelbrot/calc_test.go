@@ -2,6 +2,8 @@ package main
 
 import (
 	"math/big"
+	"moritz/go-fractals/src/complexdd"
+	"moritz/go-fractals/src/core"
 	"testing"
 )
 
@@ -74,3 +76,52 @@ func TestEquals(t *testing.T) {
 	}
 
 }
+
+func TestDivergesBackendsAgree(t *testing.T) {
+	conf = &config{maxIt: 200}
+	points := [][2]float64{{-0.75, 0.1}, {0.3, 0.6}, {-1.25, 0.01}, {0.25, 0}, {-2, 0}, {0.4, 0.3}}
+
+	for _, p := range points {
+		c := &complexBig{
+			new(big.Float).SetPrec(300).SetFloat64(p[0]),
+			new(big.Float).SetPrec(300).SetFloat64(p[1]),
+		}
+		expectedDiverged, expectedIt := diverges(c)
+
+		diverged, it := divergesFloat64(complex(p[0], p[1]))
+		if diverged != expectedDiverged || it != expectedIt {
+			t.Fatalf("float64: expected %v after %v, got %v after %v at %v",
+				expectedDiverged, expectedIt, diverged, it, p)
+		}
+		diverged, it = divergesDD(complexdd.ComplexDD{R: complexdd.DDFromBig(c.r), I: complexdd.DDFromBig(c.i)})
+		if diverged != expectedDiverged || it != expectedIt {
+			t.Fatalf("double-double: expected %v after %v, got %v after %v at %v",
+				expectedDiverged, expectedIt, diverged, it, p)
+		}
+		diverged, it = divergesQD(complexdd.ComplexQD{R: complexdd.QDFromBig(c.r), I: complexdd.QDFromBig(c.i)})
+		if diverged != expectedDiverged || it != expectedIt {
+			t.Fatalf("quad-double: expected %v after %v, got %v after %v at %v",
+				expectedDiverged, expectedIt, diverged, it, p)
+		}
+	}
+}
+
+func TestDivergesKeepsPrecision(t *testing.T) {
+	// around the Misiurewicz point i the points escape after a number of
+	// iterations that depends on their distance to i, which is below 1e-69
+	conf = createConfig([]string{"--width=30", "--height=20", "--maxIt=1000",
+		"--posX=0", "--posY=1", "--zoom=1e70"})
+	if conf.backend != core.BigFloat {
+		t.Fatalf("expected the %v backend, got %v", core.BigFloat, conf.backend)
+	}
+
+	counts := map[int]bool{}
+	for y := 0; y < conf.height; y += 4 {
+		for x := 0; x < conf.width; x += 4 {
+			counts[escapeCount(x, y)] = true
+		}
+	}
+	if len(counts) < 3 {
+		t.Fatalf("expected at least 3 different escape counts, got %v", counts)
+	}
+}
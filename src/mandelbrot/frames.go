@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+)
+
+// renderFrame renders the current config as a numbered png in out and adds it
+// to the animation
+func renderFrame(frame int, out string, anim *gif.GIF, delay int) {
+	img = createImg()
+	measureTime(drawPartially)
+
+	savePNG(filepath.Join(out, fmt.Sprintf("frame_%04d.png", frame)), img.img)
+
+	anim.Image = append(anim.Image, toPaletted(img.img))
+	anim.Delay = append(anim.Delay, delay)
+}
+
+func saveGIF(path string, anim *gif.GIF) {
+	file, err := os.Create(path)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	if err := gif.EncodeAll(file, anim); err != nil {
+		panic(err)
+	}
+}
+
+// grayPalette contains all 256 shades of gray, which covers every color the
+// renderer produces
+var grayPalette = func() color.Palette {
+	p := make(color.Palette, 256)
+	for i := range p {
+		p[i] = color.Gray{uint8(i)}
+	}
+	return p
+}()
+
+func toPaletted(src *image.RGBA) *image.Paletted {
+	dst := image.NewPaletted(src.Bounds(), grayPalette)
+	for y := src.Bounds().Min.Y; y < src.Bounds().Max.Y; y++ {
+		for x := src.Bounds().Min.X; x < src.Bounds().Max.X; x++ {
+			dst.Set(x, y, src.At(x, y))
+		}
+	}
+	return dst
+}
@@ -3,44 +3,62 @@ package main
 import (
 	"image"
 	"math/big"
-	"os"
+	"moritz/go-fractals/src/core"
+	"moritz/go-fractals/src/fill"
 	"strconv"
 	"strings"
 )
 
 type config struct {
-	width    int
-	height   int
-	xMax     *big.Float
-	xMin     *big.Float
-	xDelta   *big.Float
-	yMax     *big.Float
-	yMin     *big.Float
-	yDelta   *big.Float
-	maxIt    int
-	skip     bool
+	width  int
+	height int
+	view   viewport
+	// origin is the point of pixel 0, 0, stepX and stepY are the distances
+	// between neighbouring pixels along the image axes
+	origin *complexBig
+	stepX  *complexBig
+	stepY  *complexBig
+	maxIt  int
+	skip   bool
+	// prec is the precision in bits of the coordinates and backend the
+	// number type of the iteration, both are derived from the zoom by
+	// setViewport
 	prec     int
+	backend  core.Backend
 	nThreads int
+	strategy fill.Strategy
+	// samples per axis of supersampled pixels
+	samples     int
+	sampling    samplingMode
+	aaThreshold int
+	// paletteOffset shifts the color gradient, 1 is a full cycle
+	paletteOffset float64
+	// reference is shared by all frames of a zoom, points that need more
+	// than float64 precision are iterated relative to it. It is nil if
+	// every point is iterated on its own.
+	reference *referenceOrbit
 }
 
-func createConfig() *config {
+func createConfig(args []string) *config {
 	newConf := &config{
-		width:    1500,
-		height:   1000,
-		xMax:     big.NewFloat(1.0),
-		xMin:     big.NewFloat(-2.0),
-		yMax:     big.NewFloat(1.0),
-		yMin:     big.NewFloat(-1.0),
-		maxIt:    100,
-		skip:     false,
-		prec:     53,
-		nThreads: 1024,
+		width:       1500,
+		height:      1000,
+		maxIt:       100,
+		skip:        false,
+		prec:        53,
+		nThreads:    1024,
+		strategy:    fill.BruteForce,
+		samples:     1,
+		sampling:    gridSampling,
+		aaThreshold: 16,
 	}
 
-	args := os.Args[1:]
-	zoom := 1.0
-	posX := 0.0
-	posY := 0.0
+	zoom := big.NewFloat(1)
+	posX := new(big.Float)
+	posY := new(big.Float)
+	rotation := 0.0
+	skewX := 0.0
+	skewY := 0.0
 
 	for _, arg := range args {
 		argArr := strings.Split(strings.Replace(arg, "--", "", 1), "=")
@@ -50,35 +68,71 @@ func createConfig() *config {
 		case "height":
 			newConf.height, _ = strconv.Atoi(argArr[1])
 		case "posX":
-			posX, _ = strconv.ParseFloat(argArr[1], 64)
+			posX = mustParseDecimal(argArr[1])
 		case "posY":
-			posY, _ = strconv.ParseFloat(argArr[1], 64)
-			posY *= -1
+			posY = mustParseDecimal(argArr[1])
+			posY.Neg(posY)
 		case "zoom":
-			zoom, _ = strconv.ParseFloat(argArr[1], 64)
+			zoom = mustParseDecimal(argArr[1])
+			if zoom.Sign() <= 0 {
+				panic("zoom has to be positive")
+			}
+		case "rotation":
+			rotation, _ = strconv.ParseFloat(argArr[1], 64)
+		case "skewX":
+			skewX, _ = strconv.ParseFloat(argArr[1], 64)
+		case "skewY":
+			skewY, _ = strconv.ParseFloat(argArr[1], 64)
 		case "nThreads":
 			newConf.nThreads, _ = strconv.Atoi(argArr[1])
 		case "maxIt":
 			newConf.maxIt, _ = strconv.Atoi(argArr[1])
 		case "skip":
 			newConf.skip = true
+		case "strategy":
+			strategy, err := fill.ParseStrategy(argArr[1])
+			if err != nil {
+				panic(err)
+			}
+			newConf.strategy = strategy
+		case "samples":
+			newConf.samples, _ = strconv.Atoi(argArr[1])
+		case "sampling":
+			sampling, err := parseSamplingMode(argArr[1])
+			if err != nil {
+				panic(err)
+			}
+			newConf.sampling = sampling
+		case "aaThreshold":
+			newConf.aaThreshold, _ = strconv.Atoi(argArr[1])
+		case "paletteOffset":
+			newConf.paletteOffset, _ = strconv.ParseFloat(argArr[1], 64)
 		default:
 			panic("Unknown arguement " + arg)
 		}
 	}
 
-	scale := float64(newConf.width) / float64(newConf.height)
-	newConf.xMax = big.NewFloat(posX + 1/zoom*scale)
-	newConf.xMin = big.NewFloat(posX - 1/zoom*scale)
-
-	newConf.yMax = big.NewFloat(posY + 1/zoom*1)
-	newConf.yMin = big.NewFloat(posY - 1/zoom*1)
-
-	newConf.xDelta = new(big.Float).Sub(newConf.xMax, newConf.xMin)
-	newConf.yDelta = new(big.Float).Sub(newConf.yMax, newConf.yMin)
+	newConf.setViewport(viewport{
+		centerX:  posX,
+		centerY:  posY,
+		zoom:     zoom,
+		rotation: rotation,
+		skewX:    skewX,
+		skewY:    skewY,
+	})
 	return newConf
 }
 
+// mustParseDecimal parses a decimal argument with parseDecimal and panics if
+// it is invalid
+func mustParseDecimal(s string) *big.Float {
+	f, err := parseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
 func createImg() *safeImage {
 	rect := image.Rect(0, 0, conf.width, conf.height)
 	nImg := image.NewRGBA(rect)
@@ -7,8 +7,13 @@ import (
 	"image/png"
 	"math"
 	"math/big"
+	"moritz/go-fractals/src/complexdd"
+	"moritz/go-fractals/src/core"
+	"moritz/go-fractals/src/fill"
+	"moritz/go-fractals/src/utils/metrics"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,7 +21,21 @@ var img *safeImage
 var conf *config
 
 var wg sync.WaitGroup
-var done bool = false
+
+// done is set to 1 with setDone when all pixels are drawn, it is read by the
+// goroutines that print the progress and save the image
+var done int32
+
+func isDone() bool {
+	return atomic.LoadInt32(&done) != 0
+}
+
+func setDone() {
+	atomic.StoreInt32(&done, 1)
+}
+
+// nPixels counts the pixels that were drawn
+var nPixels *metrics.Counter = metrics.NewCounter()
 
 type safeImage struct {
 	img *image.RGBA
@@ -30,26 +49,109 @@ func (img *safeImage) setPixel(x, y int, c color.Color) {
 }
 
 func main() {
-	conf = createConfig()
+	if len(os.Args) > 1 && os.Args[1] == "zoom" {
+		runZoom(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "animate" {
+		runAnimation(os.Args[2:])
+		return
+	}
+
+	conf = createConfig(os.Args[1:])
 	img = createImg()
+	total := conf.width * conf.height
 	go regularSave()
+	go printProgress(2*time.Second, total)
 	measureTime(drawPartially)
+	setDone()
 	save()
-	fmt.Printf("%v/%v, %v%%", skipped, conf.width*conf.height, skipped/conf.width*conf.height)
+	fmt.Printf("%v/%v points skipped, %.2f%%\n", skipped.Value(), total, 100*float64(skipped.Value())/float64(total))
+}
+
+// printProgress prints the number of drawn pixels out of total and the pixels
+// per second over the last 10 seconds until all images are done
+func printProgress(every time.Duration, total int) {
+	rate := metrics.NewRate(nPixels, 10*time.Second)
+	rate.Sample(time.Now())
+	for !isDone() {
+		time.Sleep(every)
+		rate.Sample(time.Now())
+		fmt.Printf("%v/%v pixels, %.0f pixels / second\n", nPixels.Value(), total, rate.PerSecond())
+	}
 }
 
 func setPixelsPartially(yL, yH, xL, xH int) {
+	counts := fill.Area(conf.strategy, xH-xL, yH-yL, func(x, y int) int {
+		return escapeCount(xL+x, yL+y)
+	})
+	// pixels outside of this area are only needed for adaptive sampling
+	countAt := func(x, y int) int {
+		if x < xL || y < yL || x >= xH || y >= yH {
+			return escapeCount(x, y)
+		}
+		return counts[(y-yL)*(xH-xL)+(x-xL)]
+	}
+
 	for y := yL; y < yH; y++ {
 		for x := xL; x < xH; x++ {
-			img.setPixel(x, y, getPixelColor(x, y))
+			if needsSupersampling(x, y, countAt) {
+				img.setPixel(x, y, getPixelColor(x, y))
+				continue
+			}
+			img.setPixel(x, y, colorFromEscapeCount(countAt(x, y)))
 		}
+		nPixels.Add(int64(xH - xL))
+	}
+}
+
+// escapeCount returns the number of iterations after which the point at
+// pixel x, y diverged or conf.maxIt if it did not diverge
+func escapeCount(x, y int) int {
+	return escapeCountAt(float64(x), float64(y))
+}
+
+// escapeCountAt is escapeCount for subpixel positions, it iterates with the
+// fastest number type that is precise enough for the current zoom
+func escapeCountAt(x, y float64) int {
+	c := translate(x, y)
+
+	var diverged bool
+	var it int
+	switch {
+	case conf.reference != nil && conf.backend != core.Float64:
+		diverged, it = conf.reference.diverges(conf.reference.offset(c))
+	case conf.backend == core.Float64:
+		r, _ := c.r.Float64()
+		i, _ := c.i.Float64()
+		diverged, it = divergesFloat64(complex(r, i))
+	case conf.backend == core.DoubleDouble:
+		diverged, it = divergesDD(complexdd.ComplexDD{
+			R: complexdd.DDFromBig(c.r),
+			I: complexdd.DDFromBig(c.i),
+		})
+	case conf.backend == core.QuadDouble:
+		diverged, it = divergesQD(complexdd.ComplexQD{
+			R: complexdd.QDFromBig(c.r),
+			I: complexdd.QDFromBig(c.i),
+		})
+	default:
+		diverged, it = diverges(c)
+	}
+	if !diverged {
+		return conf.maxIt
 	}
+	return it
 }
 
-func getPixelColor(x, y int) color.Color {
-	diverged, it := diverges(translate(x, y))
-	if diverged {
-		col := uint8(math.Sqrt(float64(it)/float64(conf.maxIt)) * 255)
+func colorFromEscapeCount(it int) color.RGBA {
+	if it < conf.maxIt {
+		// the palette offset shifts the gradient cyclically
+		t := math.Mod(math.Sqrt(float64(it)/float64(conf.maxIt))+conf.paletteOffset, 1)
+		if t < 0 {
+			t++
+		}
+		col := uint8(t * 255)
 		return color.RGBA{col, col, col, 255}
 	}
 	return color.RGBA{0, 0, 0, 255}
@@ -86,23 +188,25 @@ func drawPartially() {
 		}
 	}
 	wg.Wait()
-	done = true
 	fmt.Printf("n threads: %v \n", c)
 }
 
-func translate(x, y int) *complexBig {
+func translate(x, y float64) *complexBig {
+	xBig := big.NewFloat(x)
+	yBig := big.NewFloat(y)
 
-	// x/width*(xMax-xMin)+xMin
-	r := big.NewFloat(float64(x) / float64(conf.width))
-	r = r.Mul(r, conf.xDelta)
-	r = r.Add(r, conf.xMin)
+	// origin + x*stepX + y*stepY
+	r := new(big.Float).Mul(xBig, conf.stepX.r)
+	r.Add(r, new(big.Float).Mul(yBig, conf.stepY.r))
 
-	// y/height*(yMax-yMin)+xMin
-	i := big.NewFloat(float64(y) / float64(conf.height))
-	i = i.Mul(i, conf.yDelta)
-	i = i.Add(i, conf.yMin)
+	i := new(big.Float).Mul(xBig, conf.stepX.i)
+	i.Add(i, new(big.Float).Mul(yBig, conf.stepY.i))
 
-	return &complexBig{r, i}
+	// the results take the precision of the origin
+	return &complexBig{
+		new(big.Float).Add(conf.origin.r, r),
+		new(big.Float).Add(conf.origin.i, i),
+	}
 }
 
 func measureTime(fn func()) {
@@ -113,17 +217,21 @@ func measureTime(fn func()) {
 }
 
 func regularSave() {
-	for !done {
+	for !isDone() {
 		time.Sleep(10 * time.Second)
 		save()
 	}
 }
 
 func save() {
-	file, err := os.Create("mandelbrot.png")
+	savePNG("mandelbrot.png", img.img)
+}
+
+func savePNG(path string, img *image.RGBA) {
+	file, err := os.Create(path)
 	if err != nil {
 		panic(err)
 	}
 	defer file.Close()
-	png.Encode(file, img.img)
+	png.Encode(file, img)
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"image"
+	"moritz/go-fractals/src/fill"
+	"testing"
+)
+
+func renderWith(strategy fill.Strategy) *image.RGBA {
+	conf.strategy = strategy
+	img = createImg()
+	drawPartially()
+	return img.img
+}
+
+func TestStrategiesMatchBruteForce(t *testing.T) {
+	views := [][]string{
+		{"--width=150", "--height=100", "--maxIt=50", "--nThreads=4"},
+		{"--width=120", "--height=80", "--maxIt=200", "--nThreads=1",
+			"--posX=-0.75", "--posY=0.1", "--zoom=8"},
+	}
+
+	for _, args := range views {
+		conf = createConfig(args)
+		expected := renderWith(fill.BruteForce)
+
+		for _, strategy := range []fill.Strategy{fill.MarianiSilver, fill.BoundaryTrace} {
+			actual := renderWith(strategy)
+
+			for y := 0; y < conf.height; y++ {
+				for x := 0; x < conf.width; x++ {
+					if actual.At(x, y) != expected.At(x, y) {
+						t.Fatalf("%v %v: pixel %v,%v expected %v, got %v",
+							strategy, args, x, y, expected.At(x, y), actual.At(x, y))
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+	"moritz/go-fractals/src/complexbig"
+	"os"
+	"path/filepath"
+)
+
+// maxPerturbationBits is the largest precision at which points are iterated
+// as offsets from a reference orbit. The offsets are float64, whose exponent
+// only reaches down to -1022.
+const maxPerturbationBits = 1000
+
+// referenceOrbit is the orbit of the reference point c, computed in the
+// precision of c and rounded to float64. Points near c are iterated as
+// float64 offsets from the orbit, so one orbit in full precision serves every
+// pixel of every frame that is close enough to c.
+type referenceOrbit struct {
+	c *complexBig
+	// z holds z_0 = 0 up to the point at which the reference escaped or its
+	// maxIt was reached
+	z []complex128
+}
+
+// newReferenceOrbit iterates c up to maxIt times
+func newReferenceOrbit(c *complexBig, maxIt int) *referenceOrbit {
+	ref, _ := computeReferenceOrbit(c, maxIt, nil)
+	return ref
+}
+
+// computeReferenceOrbit is newReferenceOrbit that also writes the points with
+// their full precision to ow if it is not nil. The orbit is complete even if
+// writing fails, the first error is returned with it.
+func computeReferenceOrbit(c *complexBig, maxIt int, ow *complexbig.OrbitWriter) (*referenceOrbit, error) {
+	z := &complexBig{new(big.Float).SetPrec(c.r.Prec()), new(big.Float).SetPrec(c.i.Prec())}
+	orbit := make([]complex128, 1, maxIt+1)
+	var err error
+
+	for i := 0; i < maxIt; i++ {
+		z = mul(z, z)
+		z.add(c)
+		if ow != nil && err == nil {
+			err = ow.Write(&complexbig.ComplexBig{R: z.r, I: z.i})
+		}
+
+		r, _ := z.r.Float64()
+		im, _ := z.i.Float64()
+		orbit = append(orbit, complex(r, im))
+		if r*r+im*im > 4 {
+			break
+		}
+	}
+	return &referenceOrbit{c: c, z: orbit}, err
+}
+
+// orbitHeader returns the header of the orbit file of c, which identifies it
+// by c, its precision and maxIt
+func orbitHeader(c *complexBig, maxIt int) complexbig.OrbitHeader {
+	return complexbig.OrbitHeader{
+		Prec:  c.r.Prec(),
+		Mode:  big.ToNearestEven,
+		C:     &complexbig.ComplexBig{R: c.r, I: c.i},
+		MaxIt: maxIt,
+	}
+}
+
+// orbitCachePath returns the path of the reference orbit of c in the cache
+// directory dir
+func orbitCachePath(dir string, c *complexBig, maxIt int) string {
+	h := sha256.New()
+	ow, _ := complexbig.NewOrbitWriter(h, orbitHeader(c, maxIt))
+	ow.Flush()
+	return filepath.Join(dir, fmt.Sprintf("orbit-%x.bin", h.Sum(nil)[:8]))
+}
+
+// cachedReferenceOrbit returns the reference orbit of c from the cache
+// directory dir if it was computed before with the same precision and maxIt,
+// otherwise it is computed and saved to dir. cached reports whether the orbit
+// was loaded. If the new orbit cannot be saved, it is returned together with
+// the error.
+func cachedReferenceOrbit(dir string, c *complexBig, maxIt int) (ref *referenceOrbit, cached bool, err error) {
+	path := orbitCachePath(dir, c, maxIt)
+
+	// a damaged or mismatching file is replaced by a new orbit
+	if ref, err := loadReferenceOrbit(path, c, maxIt); err == nil {
+		return ref, true, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return newReferenceOrbit(c, maxIt), false, err
+	}
+	ref, err = saveReferenceOrbit(path, c, maxIt)
+	return ref, false, err
+}
+
+// saveReferenceOrbit computes the reference orbit of c and writes it to the
+// file at path while it is computed. The file is replaced atomically, so
+// concurrent renders never load a partial orbit.
+func saveReferenceOrbit(path string, c *complexBig, maxIt int) (*referenceOrbit, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return newReferenceOrbit(c, maxIt), err
+	}
+	defer os.Remove(tmp.Name())
+
+	ow, err := complexbig.NewOrbitWriter(tmp, orbitHeader(c, maxIt))
+	if err != nil {
+		tmp.Close()
+		return newReferenceOrbit(c, maxIt), err
+	}
+	ref, err := computeReferenceOrbit(c, maxIt, ow)
+	if err == nil {
+		err = ow.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ref, err
+	}
+	return ref, os.Rename(tmp.Name(), path)
+}
+
+// loadReferenceOrbit reads the reference orbit of c from the file at path, it
+// fails if the file holds the orbit of another point, precision or maxIt
+func loadReferenceOrbit(path string, c *complexBig, maxIt int) (*referenceOrbit, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	or, err := complexbig.NewOrbitReader(file)
+	if err != nil {
+		return nil, err
+	}
+	expected := orbitHeader(c, maxIt)
+	header := or.Header()
+	if header.Prec != expected.Prec || header.MaxIt != expected.MaxIt || !header.C.Equals(expected.C) {
+		return nil, fmt.Errorf("%v holds the orbit of another point", path)
+	}
+
+	orbit := make([]complex128, 1, maxIt+1)
+	for {
+		z, err := or.Read()
+		if err == io.EOF {
+			return &referenceOrbit{c: c, z: orbit}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		r, _ := z.R.Float64()
+		i, _ := z.I.Float64()
+		orbit = append(orbit, complex(r, i))
+	}
+}
+
+// offset returns c - ref.c, which is exact up to float64 precision because
+// it is computed before rounding
+func (ref *referenceOrbit) offset(c *complexBig) complex128 {
+	r, _ := new(big.Float).Sub(c.r, ref.c.r).Float64()
+	i, _ := new(big.Float).Sub(c.i, ref.c.i).Float64()
+	return complex(r, i)
+}
+
+// diverges is diverges for the point ref.c + dc. With z_n = Z_n + dz_n, where
+// Z is the reference orbit, the offset follows
+// dz_n+1 = 2 * Z_n * dz_n + dz_n^2 + dc.
+// When the point gets closer to 0 than to the reference, or the reference
+// orbit ends, the offset is rebased onto the start of the orbit, which avoids
+// the precision loss of offsets that are large compared to z.
+func (ref *referenceOrbit) diverges(dc complex128) (bool, int) {
+	dz := complex(0, 0)
+	m := 0
+
+	for i := 0; i < conf.maxIt; i++ {
+		dz = 2*ref.z[m]*dz + dz*dz + dc
+		m++
+
+		z := ref.z[m] + dz
+		zAbsSq := real(z)*real(z) + imag(z)*imag(z)
+		if zAbsSq > 4 {
+			return true, i
+		}
+		if zAbsSq < real(dz)*real(dz)+imag(dz)*imag(dz) || m == len(ref.z)-1 {
+			dz = z
+			m = 0
+		}
+	}
+	return false, conf.maxIt
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math/big"
+	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/core"
+	"os"
+	"testing"
+)
+
+func TestPerturbationMatchesDirectIteration(t *testing.T) {
+	// around the Misiurewicz point i the escape counts grow with the
+	// distance to i
+	conf = createConfig([]string{"--width=30", "--height=20", "--maxIt=500",
+		"--posX=0", "--posY=1", "--zoom=1e20"})
+	// the reference is offset from the center like the target of a zoom
+	// that is not reached yet
+	const prec = 256
+	ctx := complexbig.NewContext(prec)
+	c := translate(9.5, 5.25)
+	conf.reference = newReferenceOrbit(&complexBig{
+		new(big.Float).SetPrec(prec).Set(c.r),
+		new(big.Float).SetPrec(prec).Set(c.i),
+	}, conf.maxIt)
+
+	counts := map[int]bool{}
+	differ := 0
+	for y := 0; y < conf.height; y++ {
+		for x := 0; x < conf.width; x++ {
+			c := translate(float64(x), float64(y))
+			trajectory, inSet := core.IterateBig(ctx, &complexbig.ComplexBig{R: c.r, I: c.i}, conf.maxIt)
+			it := conf.maxIt
+			if !inSet {
+				it = len(trajectory)
+			}
+			counts[it] = true
+
+			diverged, perturbedIt := conf.reference.diverges(conf.reference.offset(c))
+			if diverged == inSet || perturbedIt != it {
+				differ++
+			}
+		}
+	}
+	if len(counts) < 5 {
+		t.Fatalf("expected a view with varied escape counts, got %v", counts)
+	}
+	// rounding decides the escape count of a few points next to the set
+	if differ > conf.width*conf.height/100 {
+		t.Fatalf("expected at most 1%% of the points to differ, %v did", differ)
+	}
+}
+
+func TestReferenceOrbitEscapes(t *testing.T) {
+	conf = createConfig([]string{"--maxIt=100"})
+	ref := newReferenceOrbit(&complexBig{big.NewFloat(0.5), big.NewFloat(0.5)}, conf.maxIt)
+	diverged, it := diverges(&complexBig{big.NewFloat(0.5), big.NewFloat(0.5)})
+	if !diverged || len(ref.z) != it+2 {
+		t.Fatalf("expected the orbit to end after escaping at %v, got %v points", it, len(ref.z))
+	}
+
+	// points continue after the reference escaped
+	for _, c := range []complex128{complex(0.5, 0.49), complex(0, 0.1), complex(-1, 0.01)} {
+		expectedDiverged, expectedIt := diverges(&complexBig{big.NewFloat(real(c)), big.NewFloat(imag(c))})
+		diverged, it := ref.diverges(c - complex(0.5, 0.5))
+		if diverged != expectedDiverged || it != expectedIt {
+			t.Fatalf("%v: expected %v %v, got %v %v", c, expectedDiverged, expectedIt, diverged, it)
+		}
+	}
+}
+
+func TestCachedReferenceOrbit(t *testing.T) {
+	dir := t.TempDir()
+	c := &complexBig{
+		new(big.Float).SetPrec(200).SetFloat64(-0.75),
+		new(big.Float).SetPrec(200).SetFloat64(0.1),
+	}
+	c.i.Add(c.i, new(big.Float).SetMantExp(big.NewFloat(1), -150))
+
+	ref, cached, err := cachedReferenceOrbit(dir, c, 300)
+	if err != nil || cached {
+		t.Fatalf("expected a new orbit, got cached %v (%v)", cached, err)
+	}
+	loaded, cached, err := cachedReferenceOrbit(dir, c, 300)
+	if err != nil || !cached {
+		t.Fatalf("expected a cached orbit, got cached %v (%v)", cached, err)
+	}
+	if len(loaded.z) != len(ref.z) {
+		t.Fatalf("expected %v points, got %v", len(ref.z), len(loaded.z))
+	}
+	for i := range ref.z {
+		if loaded.z[i] != ref.z[i] {
+			t.Fatalf("%v: expected %v, got %v", i, ref.z[i], loaded.z[i])
+		}
+	}
+
+	// another maxIt, precision or point does not match the cached orbit
+	if _, cached, _ := cachedReferenceOrbit(dir, c, 301); cached {
+		t.Fatalf("expected a new orbit for another maxIt")
+	}
+	lower := &complexBig{new(big.Float).SetPrec(100).Set(c.r), new(big.Float).SetPrec(100).Set(c.i)}
+	if _, cached, _ := cachedReferenceOrbit(dir, lower, 300); cached {
+		t.Fatalf("expected a new orbit for another precision")
+	}
+	other := &complexBig{new(big.Float).SetPrec(200).Set(c.r), new(big.Float).SetPrec(200).SetFloat64(0.1)}
+	if _, cached, _ := cachedReferenceOrbit(dir, other, 300); cached {
+		t.Fatalf("expected a new orbit for another point")
+	}
+
+	// a damaged file is replaced
+	if err := os.WriteFile(orbitCachePath(dir, c, 300), []byte("ORBT"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached, err := cachedReferenceOrbit(dir, c, 300); err != nil || cached {
+		t.Fatalf("expected a new orbit, got cached %v (%v)", cached, err)
+	}
+	if _, cached, _ := cachedReferenceOrbit(dir, c, 300); !cached {
+		t.Fatalf("expected the replaced orbit to be cached")
+	}
+}
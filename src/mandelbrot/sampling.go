@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// samplingMode defines where the samples of a supersampled pixel are taken
+type samplingMode int
+
+const (
+	// gridSampling takes samples x samples points on a regular grid
+	gridSampling samplingMode = iota
+	// jitterSampling takes one random point in each cell of the grid
+	jitterSampling
+	// adaptiveSampling only supersamples pixels whose neighbours differ by
+	// more than conf.aaThreshold, using the regular grid
+	adaptiveSampling
+)
+
+func parseSamplingMode(name string) (samplingMode, error) {
+	switch name {
+	case "grid":
+		return gridSampling, nil
+	case "jitter":
+		return jitterSampling, nil
+	case "adaptive":
+		return adaptiveSampling, nil
+	}
+	return gridSampling, fmt.Errorf("unknown sampling mode %q", name)
+}
+
+// getPixelColor supersamples the pixel x, y with conf.samples x conf.samples
+// points, the samples are centered around the point of the pixel that is
+// used without supersampling
+func getPixelColor(x, y int) color.RGBA {
+	n := conf.samples
+	colors := make([]color.RGBA, 0, n*n)
+	for sy := 0; sy < n; sy++ {
+		for sx := 0; sx < n; sx++ {
+			dx, dy := 0.5, 0.5
+			if conf.sampling == jitterSampling {
+				dx, dy = rand.Float64(), rand.Float64()
+			}
+			it := escapeCountAt(
+				float64(x)+(float64(sx)+dx)/float64(n)-0.5,
+				float64(y)+(float64(sy)+dy)/float64(n)-0.5)
+			colors = append(colors, colorFromEscapeCount(it))
+		}
+	}
+	return averageColors(colors)
+}
+
+// needsSupersampling checks whether a pixel differs strongly from one of its
+// neighbours, countAt returns the escape count of any pixel of the image
+func needsSupersampling(x, y int, countAt func(x, y int) int) bool {
+	if conf.samples <= 1 {
+		return false
+	}
+	if conf.sampling != adaptiveSampling {
+		return true
+	}
+
+	own := colorFromEscapeCount(countAt(x, y))
+	neighbours := [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}}
+	for _, n := range neighbours {
+		nx, ny := n[0], n[1]
+		if nx < 0 || ny < 0 || nx >= conf.width || ny >= conf.height {
+			continue
+		}
+		other := colorFromEscapeCount(countAt(nx, ny))
+		if colorDistance(own, other) > float64(conf.aaThreshold) {
+			return true
+		}
+	}
+	return false
+}
+
+// colorDistance returns the largest difference of the red, green and blue
+// channels of a and b, so that edges of any channel of the palette count
+func colorDistance(a, b color.RGBA) float64 {
+	return math.Max(math.Abs(float64(a.R)-float64(b.R)),
+		math.Max(math.Abs(float64(a.G)-float64(b.G)), math.Abs(float64(a.B)-float64(b.B))))
+}
+
+// averageColors averages colors in linear color space
+func averageColors(colors []color.RGBA) color.RGBA {
+	var r, g, b float64
+	for _, c := range colors {
+		r += toLinear(c.R)
+		g += toLinear(c.G)
+		b += toLinear(c.B)
+	}
+	n := float64(len(colors))
+	return color.RGBA{fromLinear(r / n), fromLinear(g / n), fromLinear(b / n), 255}
+}
+
+// toLinear converts an sRGB channel to linear intensity in [0, 1]
+func toLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// fromLinear converts linear intensity in [0, 1] to an sRGB channel
+func fromLinear(l float64) uint8 {
+	var c float64
+	if l <= 0.0031308 {
+		c = l * 12.92
+	} else {
+		c = 1.055*math.Pow(l, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(math.Max(0, math.Min(1, c)) * 255))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"image/color"
+	"moritz/go-fractals/src/fill"
+	"testing"
+)
+
+func TestAverageColors(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+
+	// the average of black and white is 50% linear intensity, not 128
+	avg := averageColors([]color.RGBA{black, white})
+	if avg != (color.RGBA{188, 188, 188, 255}) {
+		t.Fatalf("expected 188, got %v", avg)
+	}
+
+	grey := color.RGBA{90, 90, 90, 255}
+	avg = averageColors([]color.RGBA{grey, grey, grey})
+	if avg != grey {
+		t.Fatalf("expected %v, got %v", grey, avg)
+	}
+}
+
+func TestColorDistance(t *testing.T) {
+	a := color.RGBA{10, 20, 30, 255}
+	for _, b := range []color.RGBA{{40, 20, 30, 255}, {10, 50, 30, 255}, {10, 20, 0, 255}} {
+		if d := colorDistance(a, b); d != 30 {
+			t.Fatalf("%v: expected 30, got %v", b, d)
+		}
+	}
+	if d := colorDistance(a, color.RGBA{15, 0, 25, 255}); d != 20 {
+		t.Fatalf("expected the largest difference 20, got %v", d)
+	}
+}
+
+func TestAdaptiveSamplingOnlyChangesEdges(t *testing.T) {
+	conf = createConfig([]string{"--width=90", "--height=60", "--maxIt=50", "--nThreads=4"})
+	plain := renderWith(fill.BruteForce)
+
+	conf.samples = 3
+	conf.sampling = adaptiveSampling
+	adaptive := renderWith(fill.BruteForce)
+
+	changed := 0
+	for y := 1; y < conf.height-1; y++ {
+		for x := 1; x < conf.width-1; x++ {
+			c := plain.At(x, y)
+			uniform := plain.At(x-1, y) == c && plain.At(x+1, y) == c &&
+				plain.At(x, y-1) == c && plain.At(x, y+1) == c
+			if adaptive.At(x, y) == c {
+				continue
+			}
+			if uniform {
+				t.Fatalf("pixel %v,%v with uniform neighbours was changed", x, y)
+			}
+			changed++
+		}
+	}
+	if changed == 0 {
+		t.Fatalf("expected edge pixels to be supersampled")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/core"
+)
+
+// viewport describes the visible area of the complex plane by its center and
+// zoom, at zoom 1 the visible area is 2 units high. The area is skewed by
+// skewX and skewY and then rotated by rotation degrees around the center.
+type viewport struct {
+	centerX  *big.Float
+	centerY  *big.Float
+	zoom     *big.Float
+	rotation float64
+	// skewX shifts x proportionally to y and skewY shifts y proportionally
+	// to x
+	skewX float64
+	skewY float64
+}
+
+// parseDecimal parses a decimal string, which may be in exponential form
+// like 1e300, with enough precision to keep all of its digits
+func parseDecimal(s string) (*big.Float, error) {
+	prec := uint(math.Ceil(float64(len(s))*math.Log2(10))) + 64
+	f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	return f, err
+}
+
+// log2 returns the binary logarithm of a positive big.Float, even if it is
+// out of the range of float64
+func log2(f *big.Float) float64 {
+	mant := new(big.Float)
+	exp := f.MantExp(mant)
+	m, _ := mant.Float64()
+	return math.Log2(m) + float64(exp)
+}
+
+// pow2 returns 2^e as big.Float, even if it is out of the range of float64
+func pow2(e float64) *big.Float {
+	i := math.Floor(e)
+	f := big.NewFloat(math.Exp2(e - i))
+	return f.SetMantExp(f, int(i))
+}
+
+// precisionForZoom returns the precision in bits that is needed to tell the
+// pixels of an image with the given height apart at the given zoom, see
+// complexbig.PrecisionForPixelSize
+func precisionForZoom(zoom *big.Float, height int) uint {
+	return complexbig.PrecisionForPixelSize(pixelSizeForZoom(zoom, height))
+}
+
+// pixelSizeForZoom returns the size of a pixel of an image with the given
+// height, at zoom 1 the image is 2 units high
+func pixelSizeForZoom(zoom *big.Float, height int) *big.Float {
+	return new(big.Float).Quo(big.NewFloat(2/float64(height)), zoom)
+}
+
+// transform returns the affine matrix that maps offsets from the center of
+// the image to offsets in the complex plane, without the zoom
+func (v viewport) transform() [2][2]float64 {
+	rad := v.rotation * math.Pi / 180
+	cos, sin := math.Cos(rad), math.Sin(rad)
+
+	// rotation * skew
+	return [2][2]float64{
+		{cos - sin*v.skewY, cos*v.skewX - sin},
+		{sin + cos*v.skewY, sin*v.skewX + cos},
+	}
+}
+
+// setViewport updates the config so that v is shown. The precision and the
+// backend are derived from the zoom and the pixel steps are computed in
+// big.Float, so the center keeps its full precision and the rotation stays
+// exact relative to the pixel size at any zoom.
+func (c *config) setViewport(v viewport) {
+	c.view = v
+
+	// size of a pixel without rotation and skew, pixels are square
+	pixelSize := pixelSizeForZoom(v.zoom, c.height)
+	c.prec = int(complexbig.PrecisionForPixelSize(pixelSize))
+	c.backend = core.BackendForPrecision(uint(c.prec))
+
+	m := v.transform()
+	scaled := func(f float64) *big.Float {
+		return new(big.Float).Mul(big.NewFloat(f), pixelSize)
+	}
+	c.stepX = &complexBig{scaled(m[0][0]), scaled(m[1][0])}
+	c.stepY = &complexBig{scaled(m[0][1]), scaled(m[1][1])}
+
+	// origin = center - width/2*stepX - height/2*stepY
+	halfWidth := big.NewFloat(float64(c.width) / 2)
+	halfHeight := big.NewFloat(float64(c.height) / 2)
+
+	r := new(big.Float).Mul(halfWidth, c.stepX.r)
+	r.Add(r, new(big.Float).Mul(halfHeight, c.stepY.r))
+
+	i := new(big.Float).Mul(halfWidth, c.stepX.i)
+	i.Add(i, new(big.Float).Mul(halfHeight, c.stepY.i))
+
+	// every coordinate derived from the origin inherits its precision
+	prec := uint(c.prec)
+	c.origin = &complexBig{
+		new(big.Float).SetPrec(prec).Sub(v.centerX, r),
+		new(big.Float).SetPrec(prec).Sub(v.centerY, i),
+	}
+}
+
+// interpolateViewport returns the viewport at t in [0, 1] on the way from a
+// to b. The zoom changes exponentially and the center approaches b's center
+// at the same rate, so that the movement slows down as the view gets deeper.
+// Rotation and skew change linearly.
+func interpolateViewport(a, b viewport, t float64) viewport {
+	// the zoom may be out of the range of float64, so everything is
+	// computed from its logarithm. The zoom is derived from the nearer
+	// keyframe, so that both ends are exact.
+	l := log2(b.zoom) - log2(a.zoom)
+	zoom := new(big.Float).Mul(a.zoom, pow2(t*l))
+	if t >= 0.5 {
+		zoom = new(big.Float).Mul(b.zoom, pow2((t-1)*l))
+	}
+
+	// weight of a's center, 1 at t = 0 and 0 at t = 1:
+	// (a.zoom/zoom - a.zoom/b.zoom) / (1 - a.zoom/b.zoom)
+	weight := big.NewFloat(1 - t)
+	if l != 0 {
+		r := pow2(-l)
+		weight = new(big.Float).Sub(pow2(-t*l), r)
+		weight.Quo(weight, new(big.Float).Sub(big.NewFloat(1), r))
+	}
+
+	// the center has to resolve the offset to b's center at the new zoom,
+	// which is far below the precision of either center
+	prec := uint(math.Max(0, math.Ceil(log2(zoom)))) + 64
+	for _, c := range []*big.Float{a.centerX, a.centerY, b.centerX, b.centerY} {
+		if c.Prec() > prec {
+			prec = c.Prec()
+		}
+	}
+
+	x := new(big.Float).SetPrec(prec).Sub(a.centerX, b.centerX)
+	x.Add(x.Mul(x, weight), b.centerX)
+
+	y := new(big.Float).SetPrec(prec).Sub(a.centerY, b.centerY)
+	y.Add(y.Mul(y, weight), b.centerY)
+
+	return viewport{
+		centerX:  x,
+		centerY:  y,
+		zoom:     zoom,
+		rotation: a.rotation + (b.rotation-a.rotation)*t,
+		skewX:    a.skewX + (b.skewX-a.skewX)*t,
+		skewY:    a.skewY + (b.skewY-a.skewY)*t,
+	}
+}
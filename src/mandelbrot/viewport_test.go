@@ -0,0 +1,137 @@
+package main
+
+import (
+	"math/big"
+	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/core"
+	"testing"
+)
+
+func expectPoint(t *testing.T, z *complexBig, r, i float64) {
+	t.Helper()
+	zr, _ := z.r.Float64()
+	zi, _ := z.i.Float64()
+	if zr-r > 1e-12 || r-zr > 1e-12 || zi-i > 1e-12 || i-zi > 1e-12 {
+		t.Fatalf("expected %v+%vi, got %v+%vi", r, i, zr, zi)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	conf = createConfig([]string{"--width=300", "--height=200", "--posX=-0.5", "--zoom=2"})
+
+	expectPoint(t, translate(0, 0), -1.25, -0.5)
+	expectPoint(t, translate(150, 100), -0.5, 0)
+	expectPoint(t, translate(300, 200), 0.25, 0.5)
+}
+
+func TestTranslateRotated(t *testing.T) {
+	conf = createConfig([]string{"--width=300", "--height=200", "--posX=-0.5", "--zoom=2", "--rotation=90"})
+
+	// the center stays in place, the x axis of the image becomes the y axis
+	expectPoint(t, translate(150, 100), -0.5, 0)
+	expectPoint(t, translate(300, 100), -0.5, 0.75)
+	expectPoint(t, translate(150, 0), 0, 0)
+
+	conf = createConfig([]string{"--width=300", "--height=200", "--rotation=180"})
+	expectPoint(t, translate(0, 0), 1.5, 1)
+}
+
+func TestTranslateSkewed(t *testing.T) {
+	conf = createConfig([]string{"--width=200", "--height=200", "--skewX=0.5"})
+
+	// x is shifted by half of the offset in y
+	expectPoint(t, translate(100, 0), -0.5, -1)
+	expectPoint(t, translate(200, 200), 1.5, 1)
+}
+
+func TestTranslateRotatedDeepZoom(t *testing.T) {
+	conf = createConfig([]string{"--width=200", "--height=200", "--rotation=30"})
+
+	center, _, _ := big.ParseFloat("-1.7490212458947584793287354901234567890123", 10, 200, big.ToNearestEven)
+	conf.setViewport(viewport{centerX: center, centerY: new(big.Float), zoom: big.NewFloat(1e40), rotation: 30})
+
+	// the offset from the center is far below float64 precision of the
+	// center but is still resolved exactly
+	z := translate(200, 100)
+	offsetR, _ := new(big.Float).Sub(z.r, center).Float64()
+	offsetI, _ := z.i.Float64()
+
+	if offsetR < 0.866e-40 || offsetR > 0.867e-40 {
+		t.Fatalf("expected real offset cos(30°)*1e-40, got %v", offsetR)
+	}
+	if offsetI < 0.499e-40 || offsetI > 0.501e-40 {
+		t.Fatalf("expected imaginary offset sin(30°)*1e-40, got %v", offsetI)
+	}
+}
+
+func TestCreateConfigArbitraryPrecision(t *testing.T) {
+	x := "-1.99999911758766165543764649311537154663"
+	conf = createConfig([]string{"--width=100", "--height=100", "--posX=" + x, "--posY=1e-30", "--zoom=1e300"})
+
+	// log2(1e300 * 100) = 1003.3
+	if conf.prec != 1004+complexbig.GuardBits {
+		t.Fatalf("expected precision %v, got %v", 1004+complexbig.GuardBits, conf.prec)
+	}
+
+	expected, _, _ := big.ParseFloat(x, 10, 200, big.ToNearestEven)
+	if conf.view.centerX.Text('g', 39) != expected.Text('g', 39) {
+		t.Fatalf("expected %v, got %v", expected.Text('g', 39), conf.view.centerX.Text('g', 39))
+	}
+
+	// the center pixel is exactly the center
+	z := translate(50, 50)
+	if z.r.Cmp(conf.view.centerX) != 0 {
+		t.Fatalf("expected %v, got %v", conf.view.centerX, z.r)
+	}
+	if z.r.Prec() != uint(conf.prec) {
+		t.Fatalf("expected precision %v, got %v", conf.prec, z.r.Prec())
+	}
+
+	// neighbouring pixels can be told apart
+	if translate(51, 50).r.Cmp(z.r) != 1 {
+		t.Fatalf("expected pixel 51 to be right of pixel 50")
+	}
+}
+
+func TestInterpolateViewportBeyondFloat64(t *testing.T) {
+	a := viewport{centerX: big.NewFloat(-1), centerY: new(big.Float), zoom: big.NewFloat(1)}
+	zoom, _ := parseDecimal("1e600")
+	b := viewport{centerX: big.NewFloat(-1.5), centerY: new(big.Float), zoom: zoom}
+
+	mid := interpolateViewport(a, b, 0.5)
+	if l := log2(mid.zoom); l < 996.5 || l > 996.7 {
+		t.Fatalf("expected zoom 1e300, got %v", mid.zoom)
+	}
+
+	// the center has moved only a tiny bit less than the whole way
+	diff, _ := new(big.Float).Sub(mid.centerX, b.centerX).Float64()
+	if diff <= 0 || diff > 1e-299 {
+		t.Fatalf("expected a difference of about 0.5e-300, got %v", diff)
+	}
+}
+
+func TestBackendForZoom(t *testing.T) {
+	expected := []struct {
+		zoom    string
+		backend core.Backend
+	}{
+		{"1", core.Float64},
+		{"3e10", core.Float64},
+		{"4e10", core.DoubleDouble},
+		{"3e26", core.DoubleDouble},
+		{"4e26", core.QuadDouble},
+		{"2.5e58", core.QuadDouble},
+		{"3e58", core.BigFloat},
+	}
+	for _, e := range expected {
+		conf = createConfig([]string{"--width=1500", "--height=1000", "--zoom=" + e.zoom})
+		if conf.backend != e.backend {
+			t.Fatalf("zoom %v: expected %v, got %v", e.zoom, e.backend, conf.backend)
+		}
+		// core.IterateContext picks the same backend for the precision
+		ctx := complexbig.ContextForPixelSize(pixelSizeForZoom(conf.view.zoom, conf.height))
+		if b := core.BackendForPrecision(ctx.Prec); b != conf.backend {
+			t.Fatalf("zoom %v: expected %v for the context, got %v", e.zoom, conf.backend, b)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"image/gif"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zoomConfig holds the options of the zoom command, the start of the zoom is
+// the viewport of the regular config
+type zoomConfig struct {
+	frames      int
+	target      viewport
+	targetMaxIt int
+	// delay between frames of the gif in 100ths of a second
+	delay int
+	out   string
+	// orbitCache is the directory in which reference orbits are cached
+	// between runs, it is empty if they are not cached
+	orbitCache string
+}
+
+// createZoomConfig takes the zoom specific arguments and passes the
+// remaining ones to createConfig
+func createZoomConfig(args []string) (*config, *zoomConfig) {
+	zoomConf := &zoomConfig{
+		frames: 100,
+		delay:  4,
+		out:    "zoom",
+	}
+
+	targetX := new(big.Float)
+	targetY := new(big.Float)
+	targetZoom := big.NewFloat(1)
+	targetRotation := 0.0
+	targetMaxIt := 0
+
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		argArr := strings.Split(strings.Replace(arg, "--", "", 1), "=")
+		switch argArr[0] {
+		case "frames":
+			zoomConf.frames, _ = strconv.Atoi(argArr[1])
+		case "targetX":
+			targetX = mustParseDecimal(argArr[1])
+		case "targetY":
+			targetY = mustParseDecimal(argArr[1])
+			targetY.Neg(targetY)
+		case "targetZoom":
+			targetZoom = mustParseDecimal(argArr[1])
+			if targetZoom.Sign() <= 0 {
+				panic("targetZoom has to be positive")
+			}
+		case "targetRotation":
+			targetRotation, _ = strconv.ParseFloat(argArr[1], 64)
+		case "targetMaxIt":
+			targetMaxIt, _ = strconv.Atoi(argArr[1])
+		case "delay":
+			zoomConf.delay, _ = strconv.Atoi(argArr[1])
+		case "out":
+			zoomConf.out = argArr[1]
+		case "orbitCache":
+			zoomConf.orbitCache = argArr[1]
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	newConf := createConfig(rest)
+
+	// the target is parsed once and every frame is derived from it, so no
+	// precision is lost between frames, see runZoom for the reference
+	zoomConf.target = viewport{
+		centerX:  targetX,
+		centerY:  targetY,
+		zoom:     targetZoom,
+		rotation: targetRotation,
+		skewX:    newConf.view.skewX,
+		skewY:    newConf.view.skewY,
+	}
+	zoomConf.targetMaxIt = newConf.maxIt
+	if targetMaxIt > 0 {
+		zoomConf.targetMaxIt = targetMaxIt
+	}
+	if zoomConf.frames < 2 {
+		panic("a zoom needs at least 2 frames")
+	}
+	return newConf, zoomConf
+}
+
+// runZoom renders the frames of a zoom from the configured viewport to the
+// target, saves them as numbered pngs and combines them into an animated gif
+func runZoom(args []string) {
+	var zoomConf *zoomConfig
+	conf, zoomConf = createZoomConfig(args)
+
+	if err := os.MkdirAll(zoomConf.out, 0755); err != nil {
+		panic(err)
+	}
+
+	start := conf.view
+	startMaxIt := conf.maxIt
+	anim := &gif.GIF{}
+
+	// the frames approach the target, so its orbit serves as the reference
+	// of every frame. It is computed once with the precision of the deepest
+	// frame and the highest maxIt.
+	if prec := precisionForZoom(zoomConf.target.zoom, conf.height); prec <= maxPerturbationBits {
+		maxIt := startMaxIt
+		if zoomConf.targetMaxIt > maxIt {
+			maxIt = zoomConf.targetMaxIt
+		}
+		c := &complexBig{
+			new(big.Float).SetPrec(prec).Set(zoomConf.target.centerX),
+			new(big.Float).SetPrec(prec).Set(zoomConf.target.centerY),
+		}
+		if zoomConf.orbitCache == "" {
+			conf.reference = newReferenceOrbit(c, maxIt)
+			fmt.Printf("Reference orbit with %v bits and %v iterations computed\n", prec, len(conf.reference.z)-1)
+		} else {
+			var cached bool
+			var err error
+			conf.reference, cached, err = cachedReferenceOrbit(zoomConf.orbitCache, c, maxIt)
+			if err != nil {
+				fmt.Println("Could not cache the reference orbit:", err)
+			}
+			if cached {
+				fmt.Printf("Reference orbit with %v bits and %v iterations loaded from %s\n", prec, len(conf.reference.z)-1, zoomConf.orbitCache)
+			} else {
+				fmt.Printf("Reference orbit with %v bits and %v iterations computed\n", prec, len(conf.reference.z)-1)
+			}
+		}
+	}
+
+	go printProgress(2*time.Second, zoomConf.frames*conf.width*conf.height)
+	for frame := 0; frame < zoomConf.frames; frame++ {
+		t := float64(frame) / float64(zoomConf.frames-1)
+
+		conf.setViewport(interpolateViewport(start, zoomConf.target, t))
+		// t is linear in the logarithm of the zoom, so maxIt grows
+		// logarithmically with the zoom
+		conf.maxIt = startMaxIt + int(math.Round(float64(zoomConf.targetMaxIt-startMaxIt)*t))
+
+		renderFrame(frame, zoomConf.out, anim, zoomConf.delay)
+		fmt.Printf("frame %v/%v, zoom %.3g, maxIt %v\n",
+			frame+1, zoomConf.frames, conf.view.zoom, conf.maxIt)
+	}
+	setDone()
+
+	saveGIF(filepath.Join(zoomConf.out, "zoom.gif"), anim)
+}
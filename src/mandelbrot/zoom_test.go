@@ -0,0 +1,83 @@
+package main
+
+import (
+	"image/gif"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpolateViewport(t *testing.T) {
+	a := viewport{centerX: big.NewFloat(0), centerY: big.NewFloat(0), zoom: big.NewFloat(1)}
+	b := viewport{centerX: big.NewFloat(-0.75), centerY: big.NewFloat(0.1), zoom: big.NewFloat(1000)}
+
+	start := interpolateViewport(a, b, 0)
+	if start.centerX.Cmp(a.centerX) != 0 || start.centerY.Cmp(a.centerY) != 0 || start.zoom.Cmp(a.zoom) != 0 {
+		t.Fatalf("expected %v %v %v, got %v %v %v",
+			a.centerX, a.centerY, a.zoom, start.centerX, start.centerY, start.zoom)
+	}
+
+	end := interpolateViewport(a, b, 1)
+	if end.centerX.Cmp(b.centerX) != 0 || end.centerY.Cmp(b.centerY) != 0 || end.zoom.Cmp(b.zoom) != 0 {
+		t.Fatalf("expected %v %v %v, got %v %v %v",
+			b.centerX, b.centerY, b.zoom, end.centerX, end.centerY, end.zoom)
+	}
+
+	// the zoom is interpolated exponentially
+	mid := interpolateViewport(a, b, 0.5)
+	if z, _ := mid.zoom.Float64(); z < 31.6 || z > 31.7 {
+		t.Fatalf("expected zoom sqrt(1000), got %v", mid.zoom)
+	}
+}
+
+func TestRunZoom(t *testing.T) {
+	out := t.TempDir()
+	runZoom([]string{"--width=30", "--height=20", "--nThreads=1", "--frames=3",
+		"--targetX=-0.75", "--targetY=0.1", "--targetZoom=10", "--targetMaxIt=200",
+		"--out=" + out})
+
+	if conf.maxIt != 200 {
+		t.Fatalf("expected maxIt 200 in the last frame, got %v", conf.maxIt)
+	}
+	if conf.reference == nil || conf.reference.c.r.Cmp(big.NewFloat(-0.75)) != 0 {
+		t.Fatalf("expected the frames to share the reference orbit of the target")
+	}
+
+	for _, name := range []string{"frame_0000.png", "frame_0001.png", "frame_0002.png"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Fatalf("expected frame %v: %v", name, err)
+		}
+	}
+
+	file, err := os.Open(filepath.Join(out, "zoom.gif"))
+	if err != nil {
+		t.Fatalf("expected gif: %v", err)
+	}
+	defer file.Close()
+	anim, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatalf("could not decode gif: %v", err)
+	}
+	if len(anim.Image) != 3 {
+		t.Fatalf("expected 3 frames, got %v", len(anim.Image))
+	}
+}
+
+func TestRunZoomCachesReferenceOrbit(t *testing.T) {
+	cache := t.TempDir()
+	args := []string{"--width=30", "--height=20", "--nThreads=1", "--frames=2",
+		"--targetX=-0.75", "--targetY=0.1", "--targetZoom=10", "--targetMaxIt=200",
+		"--out=" + t.TempDir(), "--orbitCache=" + cache}
+	runZoom(args)
+	first := conf.reference
+	runZoom(args)
+
+	files, _ := filepath.Glob(filepath.Join(cache, "orbit-*.bin"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 cached orbit, got %v", files)
+	}
+	if len(conf.reference.z) != len(first.z) {
+		t.Fatalf("expected the cached orbit with %v points, got %v", len(first.z), len(conf.reference.z))
+	}
+}
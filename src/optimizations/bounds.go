@@ -0,0 +1,52 @@
+package optimizations
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Bounds is a rectangle in the complex plane
+type Bounds struct {
+	XMin, XMax, YMin, YMax float64
+}
+
+// DefaultBounds contains the whole set
+var DefaultBounds = Bounds{XMin: -2, XMax: 2, YMin: -1, YMax: 1}
+
+// Width is the extent of b along the real axis
+func (b Bounds) Width() float64 {
+	return b.XMax - b.XMin
+}
+
+// Height is the extent of b along the imaginary axis
+func (b Bounds) Height() float64 {
+	return b.YMax - b.YMin
+}
+
+func (b Bounds) String() string {
+	return fmt.Sprintf("%v,%v,%v,%v", b.XMin, b.XMax, b.YMin, b.YMax)
+}
+
+// ParseBounds parses bounds in the form "xMin,xMax,yMin,yMax"
+func ParseBounds(s string) (Bounds, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return Bounds{}, fmt.Errorf("expected xMin,xMax,yMin,yMax, got %q", s)
+	}
+
+	values := make([]float64, 4)
+	for k, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Bounds{}, fmt.Errorf("invalid bounds %q: %v", s, err)
+		}
+		values[k] = v
+	}
+
+	b := Bounds{XMin: values[0], XMax: values[1], YMin: values[2], YMax: values[3]}
+	if !(b.Width() > 0 && b.Height() > 0) {
+		return Bounds{}, fmt.Errorf("bounds %q are empty", s)
+	}
+	return b, nil
+}
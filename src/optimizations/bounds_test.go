@@ -0,0 +1,23 @@
+package optimizations
+
+import "testing"
+
+func TestParseBounds(t *testing.T) {
+	b, err := ParseBounds("-2, 0.5,-1.25,1.25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Bounds{XMin: -2, XMax: 0.5, YMin: -1.25, YMax: 1.25}
+	if b != expected {
+		t.Fatalf("expected %v, got %v", expected, b)
+	}
+	if parsed, _ := ParseBounds(DefaultBounds.String()); parsed != DefaultBounds {
+		t.Fatalf("expected %v, got %v", DefaultBounds, parsed)
+	}
+
+	for _, s := range []string{"", "1,2,3", "a,1,2,3", "1,1,0,1", "0,1,1,0"} {
+		if _, err := ParseBounds(s); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+}
@@ -5,127 +5,261 @@ import (
 	"math/big"
 	"moritz/go-fractals/src/complexbig"
 	"moritz/go-fractals/src/core"
+	"moritz/go-fractals/src/fill"
+	"sync"
+	"time"
 
 	"github.com/schollz/progressbar/v3"
 )
 
-type ComplexInSet struct {
-	z     *complexbig.ComplexBig
+type ComplexInSet[T any] struct {
+	z     T
 	inSet bool
 }
-type Grid struct {
-	values                 [][]ComplexInSet
-	xMin, xMax, yMin, yMax float64
-	nLanes                 int
+
+// Grid holds for points on a regular grid whether they are in the set. The
+// points are numbers of type T, see core.Arithmetic.
+type Grid[T any] struct {
+	values [][]ComplexInSet[T]
+	bounds Bounds
+	// nX and nY are the number of grid points along the real and imaginary
+	// axis, the outermost points lie on the bounds
+	nX, nY int
+	maxIt  int
+	// strategy is the fill strategy the membership was computed with
+	strategy fill.Strategy
+	arith    core.Arithmetic[T]
+	// buildTime is the time it took to compute the points, it is 0 for
+	// loaded grids
+	buildTime time.Duration
 }
 
-func NewGrid(nLanes, maxIt, maxThreads int) *Grid {
+// NewGrid creates a grid of nX x nY points within bounds and checks for each
+// point whether it is in the set. strategy selects how the points are
+// computed, fill.BoundaryTrace and fill.MarianiSilver skip most points inside
+// and outside of the set but miss minibrots and filaments that are islands at
+// grid resolution, see fill.Area. The points are iterated with arith.
+func NewGrid[T any](arith core.Arithmetic[T], bounds Bounds, nX, nY, maxIt, maxThreads int, strategy fill.Strategy) *Grid[T] {
+	if err := checkGridSize(bounds, nX, nY); err != nil {
+		panic(err)
+	}
+	grid := newEmptyGrid(arith, bounds, nX, nY, maxIt)
+	grid.strategy = strategy
 
-	values := make([][]ComplexInSet, nLanes)
-	for i := range values {
-		values[i] = make([]ComplexInSet, nLanes)
+	start := time.Now()
+	fillGrid(grid, maxIt, maxThreads, strategy)
+	grid.buildTime = time.Since(start)
+
+	return grid
+}
+
+func checkGridSize(bounds Bounds, nX, nY int) error {
+	if nX < 2 || nY < 2 {
+		return fmt.Errorf("a grid needs at least 2 points along each axis, got %v x %v", nX, nY)
+	}
+	if !(bounds.Width() > 0 && bounds.Height() > 0) {
+		return fmt.Errorf("the bounds %v of a grid must not be empty", bounds)
 	}
+	return nil
+}
 
-	grid := &Grid{values: values,
-		xMin: -2, xMax: 2,
-		yMin: -1, yMax: 1,
-		nLanes: nLanes}
+func newEmptyGrid[T any](arith core.Arithmetic[T], bounds Bounds, nX, nY, maxIt int) *Grid[T] {
+	values := make([][]ComplexInSet[T], nX)
+	for i := range values {
+		values[i] = make([]ComplexInSet[T], nY)
+	}
 
-	fillGrid(grid, maxIt, maxThreads)
+	return &Grid[T]{values: values,
+		bounds: bounds,
+		nX:     nX,
+		nY:     nY,
+		maxIt:  maxIt,
+		arith:  arith}
+}
 
-	return grid
+// Bounds returns the rectangle that is covered by the grid
+func (grid *Grid[T]) Bounds() Bounds {
+	return grid.bounds
 }
 
-func PrintGridValue(i, j int, grid *Grid) {
+func PrintGridValue[T any](i, j int, grid *Grid[T]) {
 	z := grid.values[i][j].z
 	if grid.values[i][j].inSet {
-		fmt.Printf(z.String() + " is in the set\n")
+		fmt.Printf("%v is in the set\n", z)
 	} else {
-		fmt.Printf(z.String() + " is not in the set\n")
+		fmt.Printf("%v is not in the set\n", z)
 	}
 }
 
-func fillGrid(grid *Grid, maxIt, maxThreads int) {
-	bar := progressbar.Default(int64(grid.nLanes * grid.nLanes))
+// fillGrid splits the grid into stripes of columns that are computed
+// concurrently with the given strategy
+func fillGrid[T any](grid *Grid[T], maxIt, maxThreads int, strategy fill.Strategy) {
+	bar := progressbar.Default(int64(grid.nX * grid.nY))
 	guard := make(chan bool, maxThreads)
-	for i := 0; i < grid.nLanes; i++ {
-		for j := 0; j < grid.nLanes; j++ {
-			guard <- true
-			go func(i, j int) {
-				z := getZ(i, j, grid)
-				_, inSet := core.Iterate(z, maxIt)
-				grid.values[i][j] = ComplexInSet{
-					z: z, inSet: inSet,
-				}
-				bar.Add(1)
-				<-guard
-			}(i, j)
+	var wg sync.WaitGroup
 
-		}
+	nStripes := maxThreads * 4
+	if nStripes > grid.nX {
+		nStripes = grid.nX
 	}
-}
+	for s := 0; s < nStripes; s++ {
+		iL := grid.nX / nStripes * s
+		iH := grid.nX / nStripes * (s + 1)
+		if s == nStripes-1 {
+			iH = grid.nX
+		}
 
-func IsAtBorder(z *complexbig.ComplexBig, grid *Grid) bool {
-	minI := 0 // min i for which grid.values[i].R is larget than z.R
-	minJ := 0 // min j for which grid.values[i].I is larget than z.I
+		guard <- true
+		wg.Add(1)
+		go func(iL, iH int) {
+			defer wg.Done()
+			inSet := fill.Area(strategy, iH-iL, grid.nY, func(x, y int) int {
+				_, inSet := core.IterateWith(grid.arith, getZ(iL+x, y, grid), maxIt)
+				bar.Add(1)
+				if inSet {
+					return 1
+				}
+				return 0
+			})
 
-	for i := 0; i < grid.nLanes; i++ {
-		if grid.values[i][0].z.R.Cmp(z.R) == 1 {
-			minI = i
-			break
-		}
+			for i := iL; i < iH; i++ {
+				for j := 0; j < grid.nY; j++ {
+					grid.values[i][j] = ComplexInSet[T]{
+						z:     getZ(i, j, grid),
+						inSet: inSet[j*(iH-iL)+(i-iL)] == 1,
+					}
+				}
+			}
+			<-guard
+		}(iL, iH)
 	}
+	wg.Wait()
+	bar.Finish()
+}
 
-	for j := 0; j < grid.nLanes; j++ {
-		if grid.values[0][j].z.I.Cmp(z.I) == 1 {
-			minJ = j
-			break
-		}
-	}
-	a := grid.values[minI][minJ].inSet
-	b := grid.values[minI][minJ-1].inSet
-	c := grid.values[minI-1][minJ-1].inSet
-	d := grid.values[minI-1][minJ].inSet
+// BorderMap tells whether points are close to the border of the set, it is
+// implemented by Grid and Quadtree
+type BorderMap interface {
+	IsAtBorder(z *complexbig.ComplexBig) bool
+	// IsAtBorderFloat64 is IsAtBorder for the point x+y*i in float64
+	// precision, which is enough away from the lines between cells
+	IsAtBorderFloat64(x, y float64) bool
+	// Bounds is the region covered by the map, points outside of it are
+	// never at the border
+	Bounds() Bounds
+}
 
-	// the point is at the border if some of the 4 points around it are in
-	// the set and some are not
-	return a != b || a != c || a != d
+// IsAtBorder checks whether some of the 4 grid points around z are in the
+// set and some are not, see Grid.IsAtBorder
+func IsAtBorder[T any](z *complexbig.ComplexBig, grid *Grid[T]) bool {
+	return grid.IsAtBorder(z)
+}
 
+// IsAtBorder checks whether some of the 4 grid points around z are in the
+// set and some are not. z is compared with the grid points in full
+// precision. Points outside of the grid are never at the border.
+func (grid *Grid[T]) IsAtBorder(z *complexbig.ComplexBig) bool {
+	i, j, ok := grid.cell(z)
+	return ok && grid.isBorderCell(i, j)
 }
 
-func getZ(i, j int, grid *Grid) *complexbig.ComplexBig {
-	xDelta := (grid.xMax - grid.xMin)
-	yDelta := (grid.yMax - grid.yMin)
+// IsAtBorderFloat64 is IsAtBorder for the point x+y*i in float64 precision
+func (grid *Grid[T]) IsAtBorderFloat64(x, y float64) bool {
+	i, j, ok := grid.cellFloat64(x, y)
+	return ok && grid.isBorderCell(i, j)
+}
 
-	r := ((float64(i) / float64(grid.nLanes-1)) * xDelta) + grid.xMin
+// isBorderCell checks whether some of the 4 grid points around the cell
+// whose lower left corner is i, j are in the set and some are not
+func (grid *Grid[T]) isBorderCell(i, j int) bool {
+	a := grid.values[i+1][j+1].inSet
+	b := grid.values[i+1][j].inSet
+	c := grid.values[i][j].inSet
+	d := grid.values[i][j+1].inSet
+	return a != b || a != c || a != d
+}
+
+// cell returns the indices of the grid point at the lower left corner of the
+// cell that contains z. ok is false if z is outside of the grid.
+func (grid *Grid[T]) cell(z *complexbig.ComplexBig) (i, j int, ok bool) {
+	i, okX := cellIndex(z.R, grid.nX, func(k int) float64 {
+		r, _ := gridPoint(k, 0, grid)
+		return r
+	})
+	j, okY := cellIndex(z.I, grid.nY, func(k int) float64 {
+		_, im := gridPoint(0, k, grid)
+		return im
+	})
+	return i, j, okX && okY
+}
 
-	im := ((float64(j) / float64(grid.nLanes-1)) * yDelta) + grid.yMin
+// cellFloat64 is cell for the point zR+zI*i in float64 precision
+func (grid *Grid[T]) cellFloat64(zR, zI float64) (i, j int, ok bool) {
+	// position of z in units of the distance between grid points
+	x := (zR - grid.bounds.XMin) / grid.bounds.Width() * float64(grid.nX-1)
+	y := (zI - grid.bounds.YMin) / grid.bounds.Height() * float64(grid.nY-1)
 
-	imBig := big.NewFloat(im)
-	rBig := big.NewFloat(r)
+	// the negated comparisons also reject NaN
+	if !(x >= 0 && x <= float64(grid.nX-1) && y >= 0 && y <= float64(grid.nY-1)) {
+		return 0, 0, false
+	}
 
-	return &complexbig.ComplexBig{R: rBig, I: imBig}
+	// points on the upper edges belong to the last cell
+	i = int(x)
+	if i > grid.nX-2 {
+		i = grid.nX - 2
+	}
+	j = int(y)
+	if j > grid.nY-2 {
+		j = grid.nY - 2
+	}
+	return i, j, true
 }
 
-func IsInMainCardiod(z *complexbig.ComplexBig) bool {
-	zAbs := z.Abs()
-	zAbsSquared := new(big.Float).Mul(zAbs, zAbs)
+// cellIndex returns the index k of the interval [coord(k), coord(k+1)) that
+// contains v, where coord are n increasing grid coordinates. Points on the
+// upper edge belong to the last interval. ok is false if v is outside of
+// [coord(0), coord(n-1)].
+func cellIndex(v *big.Float, n int, coord func(k int) float64) (k int, ok bool) {
+	lower, upper := coord(0), coord(n-1)
+	if cmpFloat64(v, lower) < 0 || cmpFloat64(v, upper) > 0 {
+		return 0, false
+	}
 
-	// a = 8 * |c|^2
-	a := new(big.Float).Mul(big.NewFloat(8), zAbsSquared)
+	// the float64 estimate can be off by one next to the grid coordinates,
+	// it is corrected by comparing in full precision
+	f, _ := v.Float64()
+	k = int((f - lower) / (upper - lower) * float64(n-1))
+	if k < 0 {
+		k = 0
+	}
+	if k > n-2 {
+		k = n - 2
+	}
+	for k > 0 && cmpFloat64(v, coord(k)) < 0 {
+		k--
+	}
+	for k < n-2 && cmpFloat64(v, coord(k+1)) >= 0 {
+		k++
+	}
+	return k, true
+}
 
-	// b = a - 4
-	// b = 8 * |c|^2 - 3
-	b := new(big.Float).Sub(a, big.NewFloat(3))
+// cmpFloat64 compares x with y exactly
+func cmpFloat64(x *big.Float, y float64) int {
+	var b big.Float
+	return x.Cmp(b.SetFloat64(y))
+}
 
-	// leftSide = |c|^2 * b
-	// leftSide = |c|^2 * (8 * |c|^2 - 3)
-	leftSide := new(big.Float).Mul(zAbsSquared, b)
+// gridPoint returns the coordinates of the grid point i, j
+func gridPoint[T any](i, j int, grid *Grid[T]) (float64, float64) {
+	r := ((float64(i) / float64(grid.nX-1)) * grid.bounds.Width()) + grid.bounds.XMin
 
-	// rightSide = 3/32 * Re(z)
-	rightSide := new(big.Float).Sub(big.NewFloat(float64(3)/float64(32)), z.R)
+	im := ((float64(j) / float64(grid.nY-1)) * grid.bounds.Height()) + grid.bounds.YMin
 
-	// leftSide =< rightSide
-	return leftSide.Cmp(rightSide) <= 0
+	return r, im
+}
 
+func getZ[T any](i, j int, grid *Grid[T]) T {
+	return grid.arith.FromFloat64(gridPoint(i, j, grid))
 }
@@ -0,0 +1,227 @@
+package optimizations
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"moritz/go-fractals/src/core"
+	"moritz/go-fractals/src/fill"
+	"os"
+	"path/filepath"
+)
+
+// Formula is the iteration that the grid membership was computed with, it
+// is stored in grid files so that grids of other fractals are not reused
+const Formula = "z^2+c"
+
+// A grid file consists of "GRID", the header and the membership of the
+// points as bits, column by column. The points themselves are derived from
+// the header.
+var gridMagic = []byte("GRID")
+
+const gridVersion = 2
+
+// maxGridPoints is the largest number of points of a grid file, it keeps a
+// damaged header from allocating a huge grid
+const maxGridPoints = 1 << 30
+
+// GridHeader holds the parameters a grid was computed with
+type GridHeader struct {
+	Formula string
+	Bounds  Bounds
+	NX, NY  int
+	MaxIt   int
+	// Strategy is the fill strategy, grids of different strategies can
+	// differ because only fill.BruteForce finds islands
+	Strategy fill.Strategy
+}
+
+// gridFileHeader is the fixed size part of the header, the formula follows it
+type gridFileHeader struct {
+	Version                uint8
+	XMin, XMax, YMin, YMax float64
+	NX, NY, MaxIt          uint32
+	Strategy               uint8
+	FormulaLen             uint16
+}
+
+// Header returns the parameters of the grid
+func (grid *Grid[T]) Header() GridHeader {
+	return GridHeader{Formula: Formula, Bounds: grid.bounds, NX: grid.nX, NY: grid.nY, MaxIt: grid.maxIt, Strategy: grid.strategy}
+}
+
+// Save writes the grid to w
+func (grid *Grid[T]) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := writeGridHeader(bw, grid.Header()); err != nil {
+		return err
+	}
+
+	bits := make([]byte, (grid.nX*grid.nY+7)/8)
+	for i := 0; i < grid.nX; i++ {
+		for j := 0; j < grid.nY; j++ {
+			if grid.values[i][j].inSet {
+				k := i*grid.nY + j
+				bits[k/8] |= 1 << (k % 8)
+			}
+		}
+	}
+	if _, err := bw.Write(bits); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func writeGridHeader(w io.Writer, header GridHeader) error {
+	if _, err := w.Write(gridMagic); err != nil {
+		return err
+	}
+	fixed := gridFileHeader{
+		Version: gridVersion,
+		XMin:    header.Bounds.XMin, XMax: header.Bounds.XMax,
+		YMin: header.Bounds.YMin, YMax: header.Bounds.YMax,
+		NX: uint32(header.NX), NY: uint32(header.NY), MaxIt: uint32(header.MaxIt),
+		Strategy:   uint8(header.Strategy),
+		FormulaLen: uint16(len(header.Formula)),
+	}
+	if err := binary.Write(w, binary.LittleEndian, fixed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, header.Formula)
+	return err
+}
+
+// ReadGridHeader reads the header of a grid file
+func ReadGridHeader(r io.Reader) (GridHeader, error) {
+	magic := make([]byte, len(gridMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return GridHeader{}, err
+	}
+	if string(magic) != string(gridMagic) {
+		return GridHeader{}, errors.New("not a grid file")
+	}
+
+	var fixed gridFileHeader
+	if err := binary.Read(r, binary.LittleEndian, &fixed); err != nil {
+		return GridHeader{}, err
+	}
+	if fixed.Version != gridVersion {
+		return GridHeader{}, fmt.Errorf("unsupported grid file version %v", fixed.Version)
+	}
+	formula := make([]byte, fixed.FormulaLen)
+	if _, err := io.ReadFull(r, formula); err != nil {
+		return GridHeader{}, err
+	}
+
+	return GridHeader{
+		Formula:  string(formula),
+		Bounds:   Bounds{XMin: fixed.XMin, XMax: fixed.XMax, YMin: fixed.YMin, YMax: fixed.YMax},
+		NX:       int(fixed.NX),
+		NY:       int(fixed.NY),
+		MaxIt:    int(fixed.MaxIt),
+		Strategy: fill.Strategy(fixed.Strategy),
+	}, nil
+}
+
+// LoadGrid reads a grid that was saved with Save, its points are created with
+// arith
+func LoadGrid[T any](arith core.Arithmetic[T], r io.Reader) (*Grid[T], error) {
+	br := bufio.NewReader(r)
+	header, err := ReadGridHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	if header.Formula != Formula {
+		return nil, fmt.Errorf("grid was computed for %q, expected %q", header.Formula, Formula)
+	}
+	if err := checkGridSize(header.Bounds, header.NX, header.NY); err != nil {
+		return nil, err
+	}
+	if header.NX*header.NY > maxGridPoints {
+		return nil, fmt.Errorf("grid of %v x %v points is larger than %v points", header.NX, header.NY, maxGridPoints)
+	}
+
+	// the bits are read before the grid is allocated, so a truncated file
+	// fails with memory in proportion to its size
+	n := int64(header.NX*header.NY+7) / 8
+	var bits bytes.Buffer
+	if _, err := io.CopyN(&bits, br, n); err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+
+	grid := newEmptyGrid(arith, header.Bounds, header.NX, header.NY, header.MaxIt)
+	grid.strategy = header.Strategy
+	for i := 0; i < grid.nX; i++ {
+		for j := 0; j < grid.nY; j++ {
+			k := i*grid.nY + j
+			grid.values[i][j] = ComplexInSet[T]{
+				z:     getZ(i, j, grid),
+				inSet: bits.Bytes()[k/8]&(1<<(k%8)) != 0,
+			}
+		}
+	}
+	return grid, nil
+}
+
+// SaveGrid writes the grid to the file at path. The file is replaced
+// atomically, so concurrent readers never see a partial grid.
+func SaveGrid[T any](path string, grid *Grid[T]) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := grid.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadGridFile reads the grid from the file at path
+func LoadGridFile[T any](arith core.Arithmetic[T], path string) (*Grid[T], error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadGrid(arith, file)
+}
+
+// GridCachePath returns the path of the grid with the given parameters in the
+// cache directory dir
+func GridCachePath(dir string, header GridHeader) string {
+	h := sha256.New()
+	writeGridHeader(h, header)
+	return filepath.Join(dir, fmt.Sprintf("grid-%dx%d-%x.bin", header.NX, header.NY, h.Sum(nil)[:8]))
+}
+
+// CachedGrid returns the grid with the given parameters from the cache
+// directory dir if it exists there, otherwise it is created with NewGrid and
+// saved to dir. cached reports whether the grid was loaded. If the new grid
+// cannot be saved, it is returned together with the error.
+func CachedGrid[T any](dir string, arith core.Arithmetic[T], bounds Bounds, nX, nY, maxIt, maxThreads int, strategy fill.Strategy) (grid *Grid[T], cached bool, err error) {
+	header := GridHeader{Formula: Formula, Bounds: bounds, NX: nX, NY: nY, MaxIt: maxIt, Strategy: strategy}
+	path := GridCachePath(dir, header)
+
+	// a damaged or mismatching file is replaced by a new grid
+	if grid, err := LoadGridFile(arith, path); err == nil && grid.Header() == header {
+		return grid, true, nil
+	}
+
+	grid = NewGrid(arith, bounds, nX, nY, maxIt, maxThreads, strategy)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return grid, false, err
+	}
+	return grid, false, SaveGrid(path, grid)
+}
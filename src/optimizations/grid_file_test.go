@@ -0,0 +1,123 @@
+package optimizations
+
+import (
+	"bytes"
+	"moritz/go-fractals/src/core"
+	"moritz/go-fractals/src/fill"
+	"os"
+	"testing"
+)
+
+func expectEqualGrids(t *testing.T, expected, actual *Grid[complex128]) {
+	t.Helper()
+	if actual.Header() != expected.Header() {
+		t.Fatalf("expected header %+v, got %+v", expected.Header(), actual.Header())
+	}
+	for i := 0; i < expected.nX; i++ {
+		for j := 0; j < expected.nY; j++ {
+			if actual.values[i][j] != expected.values[i][j] {
+				t.Fatalf("%v, %v: expected %v, got %v", i, j, expected.values[i][j], actual.values[i][j])
+			}
+		}
+	}
+}
+
+func TestGridSaveLoad(t *testing.T) {
+	bounds := Bounds{XMin: -2, XMax: 0.5, YMin: -1.25, YMax: 1.25}
+	grid := NewGrid[complex128](core.Float64Arithmetic{}, bounds, 37, 23, 50, 4, fill.BruteForce)
+
+	var buf bytes.Buffer
+	if err := grid.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// one bit per point
+	if buf.Len() > 100+(37*23+7)/8 {
+		t.Fatalf("expected a compact file, got %v bytes", buf.Len())
+	}
+	data := buf.Bytes()
+
+	loaded, err := LoadGrid[complex128](core.Float64Arithmetic{}, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectEqualGrids(t, grid, loaded)
+
+	if _, err := LoadGrid[complex128](core.Float64Arithmetic{}, bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Fatalf("expected an error for a truncated grid")
+	}
+	if _, err := LoadGrid[complex128](core.Float64Arithmetic{}, bytes.NewReader([]byte("ORBT1234"))); err == nil {
+		t.Fatalf("expected an error for a file that is not a grid")
+	}
+}
+
+func TestLoadGridChecksFormula(t *testing.T) {
+	var buf bytes.Buffer
+	header := GridHeader{Formula: "z^3+c", Bounds: DefaultBounds, NX: 2, NY: 2, MaxIt: 10}
+	if err := writeGridHeader(&buf, header); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteByte(0)
+
+	read, err := ReadGridHeader(bytes.NewReader(buf.Bytes()))
+	if err != nil || read != header {
+		t.Fatalf("expected %+v, got %+v (%v)", header, read, err)
+	}
+	if _, err := LoadGrid[complex128](core.Float64Arithmetic{}, &buf); err == nil {
+		t.Fatalf("expected an error for a grid of another formula")
+	}
+}
+
+func TestLoadGridRejectsHugeHeader(t *testing.T) {
+	for _, header := range []GridHeader{
+		{Formula: Formula, Bounds: DefaultBounds, NX: 1 << 20, NY: 1 << 20, MaxIt: 10},
+		{Formula: Formula, Bounds: DefaultBounds, NX: 1 << 15, NY: 1 << 15, MaxIt: 10},
+	} {
+		var buf bytes.Buffer
+		if err := writeGridHeader(&buf, header); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteByte(0)
+		if _, err := LoadGrid[complex128](core.Float64Arithmetic{}, &buf); err == nil {
+			t.Fatalf("%v x %v: expected an error for a header without its points", header.NX, header.NY)
+		}
+	}
+}
+
+func TestCachedGrid(t *testing.T) {
+	dir := t.TempDir()
+	arith := core.Float64Arithmetic{}
+
+	grid, cached, err := CachedGrid[complex128](dir, arith, DefaultBounds, 21, 11, 30, 2, fill.BruteForce)
+	if err != nil || cached {
+		t.Fatalf("expected a new grid, got cached %v (%v)", cached, err)
+	}
+
+	loaded, cached, err := CachedGrid[complex128](dir, arith, DefaultBounds, 21, 11, 30, 2, fill.BruteForce)
+	if err != nil || !cached {
+		t.Fatalf("expected a cached grid, got cached %v (%v)", cached, err)
+	}
+	expectEqualGrids(t, grid, loaded)
+
+	// other parameters do not match the cached grid
+	other, cached, err := CachedGrid[complex128](dir, arith, DefaultBounds, 21, 11, 31, 2, fill.BruteForce)
+	if err != nil || cached || other.maxIt != 31 {
+		t.Fatalf("expected a new grid with maxIt 31, got cached %v (%v)", cached, err)
+	}
+
+	// a grid of another strategy does not match the cached grid
+	if _, cached, err = CachedGrid[complex128](dir, arith, DefaultBounds, 21, 11, 30, 2, fill.MarianiSilver); err != nil || cached {
+		t.Fatalf("expected a new grid for another strategy, got cached %v (%v)", cached, err)
+	}
+
+	// a damaged file is replaced
+	path := GridCachePath(dir, grid.Header())
+	if err := os.WriteFile(path, []byte("GRID"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached, err = CachedGrid[complex128](dir, arith, DefaultBounds, 21, 11, 30, 2, fill.BruteForce); err != nil || cached {
+		t.Fatalf("expected a new grid, got cached %v (%v)", cached, err)
+	}
+	if _, cached, _ = CachedGrid[complex128](dir, arith, DefaultBounds, 21, 11, 30, 2, fill.BruteForce); !cached {
+		t.Fatalf("expected the replaced grid to be cached")
+	}
+}
@@ -0,0 +1,101 @@
+package optimizations
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"time"
+)
+
+// the colors of the cells in grid images
+var (
+	gridInSetColor   = color.RGBA{0, 0, 0, 255}
+	gridOutsideColor = color.RGBA{255, 255, 255, 255}
+	gridBorderColor  = color.RGBA{255, 0, 0, 255}
+)
+
+// Image renders every cell of the grid as a square of scale x scale pixels.
+// Cells at the border are red, these are the cells in which the buddhabrot
+// samples points, the others are black inside and white outside of the set.
+// The imaginary axis points up.
+func (grid *Grid[T]) Image(scale int) *image.RGBA {
+	if scale < 1 {
+		scale = 1
+	}
+	nX, nY := grid.nX-1, grid.nY-1
+	img := image.NewRGBA(image.Rect(0, 0, nX*scale, nY*scale))
+
+	for i := 0; i < nX; i++ {
+		for j := 0; j < nY; j++ {
+			c := gridOutsideColor
+			switch {
+			case grid.isBorderCell(i, j):
+				c = gridBorderColor
+			case grid.values[i][j].inSet:
+				c = gridInSetColor
+			}
+
+			y := (nY - 1 - j) * scale
+			for dx := 0; dx < scale; dx++ {
+				for dy := 0; dy < scale; dy++ {
+					img.SetRGBA(i*scale+dx, y+dy, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// SaveGridImage saves the image of the grid as png to the file at path
+func SaveGridImage[T any](path string, grid *Grid[T], scale int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, grid.Image(scale)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// GridStats summarizes the cells of a grid
+type GridStats struct {
+	// Cells is the number of cells, the grid has one point more along each
+	// axis
+	Cells       int
+	InSetCells  int
+	BorderCells int
+	// BuildTime is the time it took to compute the grid, 0 if it was loaded
+	BuildTime time.Duration
+}
+
+// Stats counts the cells of the grid. Cells whose points are all in the set
+// are InSetCells, cells at the border are only counted as BorderCells.
+func (grid *Grid[T]) Stats() GridStats {
+	stats := GridStats{Cells: (grid.nX - 1) * (grid.nY - 1), BuildTime: grid.buildTime}
+	for i := 0; i < grid.nX-1; i++ {
+		for j := 0; j < grid.nY-1; j++ {
+			switch {
+			case grid.isBorderCell(i, j):
+				stats.BorderCells++
+			case grid.values[i][j].inSet:
+				stats.InSetCells++
+			}
+		}
+	}
+	return stats
+}
+
+// BorderFraction is the fraction of cells at the border, it is the fraction
+// of uniformly sampled points that the buddhabrot sampler accepts
+func (stats GridStats) BorderFraction() float64 {
+	return float64(stats.BorderCells) / float64(stats.Cells)
+}
+
+func (stats GridStats) String() string {
+	return fmt.Sprintf("%v cells, %v in the set, %v (%.2f%%) at the border, built in %s",
+		stats.Cells, stats.InSetCells, stats.BorderCells, 100*stats.BorderFraction(), stats.BuildTime)
+}
@@ -0,0 +1,73 @@
+package optimizations
+
+import (
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGridImage(t *testing.T) {
+	// the points with i >= 2 and j >= 1 are in the set, the cells around
+	// the corner at 2, 1 are at the border
+	grid := newTestGrid(DefaultBounds, 5, 3, func(i, j int) bool { return i >= 2 && j >= 1 })
+	img := grid.Image(2)
+
+	if b := img.Bounds(); b.Dx() != 8 || b.Dy() != 4 {
+		t.Fatalf("expected an image of 8 x 4 pixels, got %v", b)
+	}
+	tests := []struct {
+		x, y     int
+		expected color.RGBA
+	}{
+		// the upper row of cells is at the top of the image
+		{0, 0, gridOutsideColor},
+		{3, 1, gridBorderColor},
+		{5, 0, gridInSetColor},
+		{7, 1, gridInSetColor},
+		// the lower row
+		{1, 3, gridOutsideColor},
+		{2, 2, gridBorderColor},
+		{6, 3, gridBorderColor},
+	}
+	for _, test := range tests {
+		if c := img.RGBAAt(test.x, test.y); c != test.expected {
+			t.Fatalf("%v, %v: expected %v, got %v", test.x, test.y, test.expected, c)
+		}
+	}
+}
+
+func TestGridStats(t *testing.T) {
+	grid := newTestGrid(DefaultBounds, 5, 3, func(i, j int) bool { return i >= 2 && j >= 1 })
+	stats := grid.Stats()
+
+	expected := GridStats{Cells: 8, InSetCells: 2, BorderCells: 4}
+	if stats != expected {
+		t.Fatalf("expected %+v, got %+v", expected, stats)
+	}
+	if stats.BorderFraction() != 0.5 {
+		t.Fatalf("expected a border fraction of 0.5, got %v", stats.BorderFraction())
+	}
+}
+
+func TestSaveGridImage(t *testing.T) {
+	grid := newTestGrid(DefaultBounds, 5, 3, func(i, j int) bool { return i >= 2 })
+	path := filepath.Join(t.TempDir(), "grid.png")
+	if err := SaveGridImage(path, grid, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 12 || b.Dy() != 6 {
+		t.Fatalf("expected an image of 12 x 6 pixels, got %v", b)
+	}
+}
@@ -0,0 +1,194 @@
+package optimizations
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/core"
+	"moritz/go-fractals/src/fill"
+	"testing"
+)
+
+// newTestGrid creates a grid of nX x nY points within bounds with the given
+// membership
+func newTestGrid(bounds Bounds, nX, nY int, inSet func(i, j int) bool) *Grid[complex128] {
+	grid := &Grid[complex128]{
+		bounds: bounds,
+		nX:     nX,
+		nY:     nY,
+		arith:  core.Float64Arithmetic{},
+	}
+	grid.values = make([][]ComplexInSet[complex128], nX)
+	for i := range grid.values {
+		grid.values[i] = make([]ComplexInSet[complex128], nY)
+		for j := range grid.values[i] {
+			grid.values[i][j] = ComplexInSet[complex128]{z: getZ(i, j, grid), inSet: inSet(i, j)}
+		}
+	}
+	return grid
+}
+
+// bigPoint converts z exactly
+func bigPoint(z complex128) *complexbig.ComplexBig {
+	return &complexbig.ComplexBig{R: big.NewFloat(real(z)), I: big.NewFloat(imag(z))}
+}
+
+// isAtBorderReference looks up the cell of z by scanning the grid
+func isAtBorderReference(z complex128, grid *Grid[complex128]) bool {
+	i := 0
+	for i < grid.nX-2 && real(grid.values[i+1][0].z) <= real(z) {
+		i++
+	}
+	j := 0
+	for j < grid.nY-2 && imag(grid.values[0][j+1].z) <= imag(z) {
+		j++
+	}
+	a := grid.values[i][j].inSet
+	return a != grid.values[i+1][j].inSet || a != grid.values[i][j+1].inSet || a != grid.values[i+1][j+1].inSet
+}
+
+func TestIsAtBorder(t *testing.T) {
+	// grid points are 1 apart in x and 0.5 in y, the points with i >= 2 are
+	// in the set
+	grid := newTestGrid(DefaultBounds, 5, 5, func(i, j int) bool { return i >= 2 })
+
+	tests := map[complex128]bool{
+		complex(-1.5, 0.2): false,
+		complex(-0.5, 0.2): true,
+		complex(0.5, -0.7): false,
+		// grid points belong to the cell to their upper right
+		complex(-1, 0):  true,
+		complex(0, 0):   false,
+		complex(-2, -1): false,
+		// the upper edges belong to the last cell
+		complex(2, 1):    false,
+		complex(-0.5, 1): true,
+		complex(-1, 1):   true,
+	}
+	for z, expected := range tests {
+		if IsAtBorder(bigPoint(z), grid) != expected {
+			t.Fatalf("%v: expected %v, got %v", z, expected, !expected)
+		}
+	}
+}
+
+func TestIsAtBorderOutsideOfGrid(t *testing.T) {
+	// every cell is at the border
+	grid := newTestGrid(DefaultBounds, 5, 5, func(i, j int) bool { return (i+j)%2 == 0 })
+
+	outside := []complex128{
+		complex(-2.001, 0), complex(2.001, 0),
+		complex(0, -1.001), complex(0, 1.001),
+		complex(-100, -100), complex(100, 100),
+		complex(math.Inf(1), 0), complex(0, math.Inf(-1)),
+	}
+	for _, z := range outside {
+		if IsAtBorder(bigPoint(z), grid) {
+			t.Fatalf("%v: expected a point outside of the grid to not be at the border", z)
+		}
+	}
+
+	// just outside in more than float64 precision
+	ctx := complexbig.NewContext(200)
+	eps := ctx.NewFloat(math.Ldexp(1, -100))
+	justOutside := &complexbig.ComplexBig{R: ctx.NewFloat(2), I: ctx.NewFloat(0)}
+	justOutside.R.Add(justOutside.R, eps)
+	if IsAtBorder(justOutside, grid) {
+		t.Fatalf("expected 2+2^-100 to be outside of the grid")
+	}
+
+	if !IsAtBorder(bigPoint(complex(-2, -1)), grid) || !IsAtBorder(bigPoint(complex(2, 1)), grid) {
+		t.Fatalf("expected the corners to be inside of the grid")
+	}
+}
+
+func TestIsAtBorderMatchesScan(t *testing.T) {
+	bounds := Bounds{XMin: -0.8, XMax: -0.7, YMin: 0.05, YMax: 0.2}
+	grid := NewGrid[complex128](core.Float64Arithmetic{}, bounds, 40, 70, 100, 4, fill.BruteForce)
+	rnd := rand.New(rand.NewSource(1))
+
+	for k := 0; k < 10000; k++ {
+		z := complex(bounds.XMin+rnd.Float64()*bounds.Width(), bounds.YMin+rnd.Float64()*bounds.Height())
+		if IsAtBorder(bigPoint(z), grid) != isAtBorderReference(z, grid) {
+			t.Fatalf("%v: expected %v", z, isAtBorderReference(z, grid))
+		}
+	}
+}
+
+// TestIsAtBorderNextToGridLines checks points that are closer to a grid
+// line than float64 can resolve
+func TestIsAtBorderNextToGridLines(t *testing.T) {
+	// the points with i >= 2 are in the set, so only the cells between
+	// x = -1 and x = 0 are at the border
+	grid := newTestGrid(DefaultBounds, 5, 5, func(i, j int) bool { return i >= 2 })
+	ctx := complexbig.NewContext(200)
+	eps := ctx.NewFloat(math.Ldexp(1, -100))
+
+	// x = -1 - 2^-100 is in the cell of column 0, which is not at the border
+	below := &complexbig.ComplexBig{R: ctx.NewFloat(-1), I: ctx.NewFloat(0.2)}
+	below.R.Sub(below.R, eps)
+	if IsAtBorder(below, grid) {
+		t.Fatalf("expected -1-2^-100 to be left of the border cells")
+	}
+	// x = -2^-100 is still in column 1
+	left := &complexbig.ComplexBig{R: ctx.NewFloat(0), I: ctx.NewFloat(0.2)}
+	left.R.Sub(left.R, eps)
+	if !IsAtBorder(left, grid) {
+		t.Fatalf("expected -2^-100 to be in a border cell")
+	}
+}
+
+func TestGridWithBounds(t *testing.T) {
+	// grid points are 0.5 apart in x and 0.25 in y
+	bounds := Bounds{XMin: -1, XMax: 1, YMin: 0, YMax: 2}
+	grid := newTestGrid(bounds, 5, 9, func(i, j int) bool { return j >= 4 })
+
+	if z := grid.values[4][8].z; z != complex(1, 2) {
+		t.Fatalf("expected the last point at 1+2i, got %v", z)
+	}
+	if z := grid.values[1][3].z; z != complex(-0.5, 0.75) {
+		t.Fatalf("expected -0.5+0.75i, got %v", z)
+	}
+
+	tests := map[complex128]bool{
+		complex(0.3, 0.8):  true,
+		complex(0.3, 1.1):  false,
+		complex(-0.9, 0.1): false,
+		complex(0, -0.1):   false,
+		complex(1.1, 0.8):  false,
+	}
+	for z, expected := range tests {
+		if IsAtBorder(bigPoint(z), grid) != expected {
+			t.Fatalf("%v: expected %v, got %v", z, expected, !expected)
+		}
+	}
+}
+
+func BenchmarkIsAtBorder(b *testing.B) {
+	grid := newTestGrid(DefaultBounds, 500, 250, func(i, j int) bool { return i*i+j*j < 250*250 })
+	z := bigPoint(complex(0.3, 0.2))
+	for i := 0; i < b.N; i++ {
+		IsAtBorder(z, grid)
+	}
+}
+
+func TestIsAtBorderFloat64MatchesBig(t *testing.T) {
+	// grid points are 0.1 apart along both axes
+	grid := newTestGrid(DefaultBounds, 41, 21, func(i, j int) bool { return (i*7+j*3)%5 < 2 })
+	rnd := rand.New(rand.NewSource(3))
+
+	for k := 0; k < 10000; k++ {
+		z := randomBig(rnd)
+		x, _ := z.R.Float64()
+		y, _ := z.I.Float64()
+
+		// skip points next to the lines between cells
+		if math.Abs(x*10-math.Round(x*10)) < 1e-6 || math.Abs(y*10-math.Round(y*10)) < 1e-6 {
+			continue
+		}
+		if grid.IsAtBorderFloat64(x, y) != grid.IsAtBorder(z) {
+			t.Fatalf("%v: expected %v", z, grid.IsAtBorder(z))
+		}
+	}
+}
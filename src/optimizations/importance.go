@@ -0,0 +1,185 @@
+package optimizations
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ImportanceMap is a sampling distribution over the cells of a regular
+// lattice within bounds. Cells are picked with a probability proportional to
+// their weight, cells with weight 0 are never picked. Pick returns a weight
+// for every sample that corrects for the non-uniform distribution: weighting
+// each sample with it gives the same expected result as sampling uniformly
+// within the cells of positive initial weight, the support of the map.
+type ImportanceMap struct {
+	bounds Bounds
+	// nX and nY are the number of cells along the real and imaginary axis
+	nX, nY int
+
+	mu sync.RWMutex
+	// cdf holds the cumulative weights of the cells
+	cdf     []float64
+	weights []float64
+	support []bool
+	nCells  int
+
+	// samples and hits are the observations of Observe per cell
+	samples []int64
+	hits    []int64
+}
+
+// NewImportanceMap creates a map of nX x nY cells within bounds. weights
+// holds the weights of the cells column by column, cell i, j is weights[i*nY+j].
+func NewImportanceMap(bounds Bounds, nX, nY int, weights []float64) (*ImportanceMap, error) {
+	if nX < 1 || nY < 1 {
+		return nil, fmt.Errorf("an importance map needs at least 1 cell along each axis, got %v x %v", nX, nY)
+	}
+	if !(bounds.Width() > 0 && bounds.Height() > 0) {
+		return nil, fmt.Errorf("the bounds %v of an importance map must not be empty", bounds)
+	}
+	if len(weights) != nX*nY {
+		return nil, fmt.Errorf("expected %v weights, got %v", nX*nY, len(weights))
+	}
+
+	m := &ImportanceMap{
+		bounds:  bounds,
+		nX:      nX,
+		nY:      nY,
+		support: make([]bool, len(weights)),
+		samples: make([]int64, len(weights)),
+		hits:    make([]int64, len(weights)),
+	}
+	for k, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("the weight of cell %v is negative", k)
+		}
+		if w > 0 {
+			m.support[k] = true
+			m.nCells++
+		}
+	}
+	if m.nCells == 0 {
+		return nil, errors.New("an importance map needs a cell with positive weight")
+	}
+	m.setWeights(weights)
+	return m, nil
+}
+
+// ImportanceFromGrid creates a map with the cells of the grid, the cells at
+// the border of the set have weight 1 and all others 0. So initially it
+// samples the same points as rejecting points that are not at the border.
+func ImportanceFromGrid[T any](grid *Grid[T]) (*ImportanceMap, error) {
+	nX, nY := grid.nX-1, grid.nY-1
+	weights := make([]float64, nX*nY)
+	for i := 0; i < nX; i++ {
+		for j := 0; j < nY; j++ {
+			if grid.isBorderCell(i, j) {
+				weights[i*nY+j] = 1
+			}
+		}
+	}
+	return NewImportanceMap(grid.bounds, nX, nY, weights)
+}
+
+// setWeights replaces the weights, the caller has to hold mu for writing or
+// own m exclusively
+func (m *ImportanceMap) setWeights(weights []float64) {
+	m.weights = weights
+	m.cdf = make([]float64, len(weights))
+	sum := 0.0
+	for k, w := range weights {
+		sum += w
+		m.cdf[k] = sum
+	}
+}
+
+// Cells returns the number of cells with positive initial weight
+func (m *ImportanceMap) Cells() int {
+	return m.nCells
+}
+
+// Bounds returns the rectangle that is covered by the map
+func (m *ImportanceMap) Bounds() Bounds {
+	return m.bounds
+}
+
+// Cell returns the rectangle of cell k
+func (m *ImportanceMap) Cell(k int) Bounds {
+	i, j := k/m.nY, k%m.nY
+	dX := m.bounds.Width() / float64(m.nX)
+	dY := m.bounds.Height() / float64(m.nY)
+	return Bounds{
+		XMin: m.bounds.XMin + float64(i)*dX,
+		XMax: m.bounds.XMin + float64(i+1)*dX,
+		YMin: m.bounds.YMin + float64(j)*dY,
+		YMax: m.bounds.YMin + float64(j+1)*dY,
+	}
+}
+
+// Pick maps the uniform random number u in [0, 1) to a cell. weight is the
+// ratio of the probability of the cell under uniform sampling of the support
+// and under the map, it is 1 on average over the picked cells.
+func (m *ImportanceMap) Pick(u float64) (cell int, weight float64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	total := m.cdf[len(m.cdf)-1]
+	target := u * total
+	cell = sort.Search(len(m.cdf), func(k int) bool { return m.cdf[k] > target })
+	// u close to 1 can round up to the total
+	if cell == len(m.cdf) {
+		cell = len(m.cdf) - 1
+		for m.weights[cell] == 0 {
+			cell--
+		}
+	}
+	return cell, total / (float64(m.nCells) * m.weights[cell])
+}
+
+// Observe records that a sample from cell contributed hits, e.g. the number
+// of points of its orbit that were drawn. It is safe for concurrent use.
+func (m *ImportanceMap) Observe(cell int, hits int) {
+	atomic.AddInt64(&m.samples[cell], 1)
+	atomic.AddInt64(&m.hits[cell], int64(hits))
+}
+
+// Adapt sets the weight of each cell of the support to its average observed
+// contribution per sample, mixed with the mean contribution of all cells by
+// the fraction mix in (0, 1]. The mixing keeps every cell of the support
+// sampled, so the weights of Pick stay bounded. Cells without observations
+// get the mean contribution.
+func (m *ImportanceMap) Adapt(mix float64) {
+	means := make([]float64, len(m.weights))
+	sum, observed := 0.0, 0
+	for k := range means {
+		samples := atomic.LoadInt64(&m.samples[k])
+		if !m.support[k] || samples == 0 {
+			continue
+		}
+		means[k] = float64(atomic.LoadInt64(&m.hits[k])) / float64(samples)
+		sum += means[k]
+		observed++
+	}
+	if sum == 0 {
+		return
+	}
+	mean := sum / float64(observed)
+
+	weights := make([]float64, len(m.weights))
+	for k := range weights {
+		if !m.support[k] {
+			continue
+		}
+		if atomic.LoadInt64(&m.samples[k]) == 0 {
+			means[k] = mean
+		}
+		weights[k] = mix*mean + (1-mix)*means[k]
+	}
+
+	m.mu.Lock()
+	m.setWeights(weights)
+	m.mu.Unlock()
+}
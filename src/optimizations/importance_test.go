@@ -0,0 +1,145 @@
+package optimizations
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestImportanceMapPick(t *testing.T) {
+	weights := []float64{1, 0, 3, 6}
+	m, err := NewImportanceMap(DefaultBounds, 2, 2, weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	counts := make([]int, len(weights))
+	n := 100000
+	for k := 0; k < n; k++ {
+		cell, _ := m.Pick(rnd.Float64())
+		counts[cell]++
+	}
+	for k, w := range weights {
+		expected := w / 10
+		got := float64(counts[k]) / float64(n)
+		if math.Abs(got-expected) > 0.01 {
+			t.Fatalf("cell %v: expected a frequency of %v, got %v", k, expected, got)
+		}
+	}
+
+	// the last cell with positive weight is picked for u close to 1
+	if cell, _ := m.Pick(math.Nextafter(1, 0)); cell != 3 {
+		t.Fatalf("expected cell 3, got %v", cell)
+	}
+}
+
+func TestImportanceMapCell(t *testing.T) {
+	m, err := NewImportanceMap(DefaultBounds, 4, 2, make([]float64, 8))
+	if err == nil {
+		t.Fatalf("expected an error for a map without positive weights")
+	}
+
+	weights := make([]float64, 8)
+	weights[5] = 1
+	m, err = NewImportanceMap(DefaultBounds, 4, 2, weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// cell 5 is the second cell of the third column
+	expected := Bounds{XMin: 0, XMax: 1, YMin: 0, YMax: 1}
+	if cell := m.Cell(5); cell != expected {
+		t.Fatalf("expected %v, got %v", expected, cell)
+	}
+	if cell, weight := m.Pick(0.5); cell != 5 || weight != 1 {
+		t.Fatalf("expected cell 5 with weight 1, got %v with %v", cell, weight)
+	}
+}
+
+// TestImportanceMapReweighting estimates the mean of f over the support with
+// weighted samples of a skewed map and compares it to the exact mean
+func TestImportanceMapReweighting(t *testing.T) {
+	nX, nY := 8, 4
+	weights := make([]float64, nX*nY)
+	for k := range weights {
+		// the first column is not part of the support
+		if k >= nY {
+			weights[k] = float64(k%5 + 1)
+		}
+	}
+	m, err := NewImportanceMap(DefaultBounds, nX, nY, weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := func(x, y float64) float64 { return x*x + y }
+
+	// the exact mean over the support x in [-1.5, 2], y in [-1, 1]
+	expected := (8 - -3.375) / 3 / 3.5
+
+	rnd := rand.New(rand.NewSource(2))
+	sum, sumWeights := 0.0, 0.0
+	n := 400000
+	for k := 0; k < n; k++ {
+		cell, weight := m.Pick(rnd.Float64())
+		b := m.Cell(cell)
+		x := b.XMin + rnd.Float64()*b.Width()
+		y := b.YMin + rnd.Float64()*b.Height()
+		sum += weight * f(x, y)
+		sumWeights += weight
+	}
+
+	if got := sum / float64(n); math.Abs(got-expected) > 0.02 {
+		t.Fatalf("expected a mean of %v, got %v", expected, got)
+	}
+	if got := sumWeights / float64(n); math.Abs(got-1) > 0.01 {
+		t.Fatalf("expected a mean weight of 1, got %v", got)
+	}
+}
+
+func TestImportanceFromGrid(t *testing.T) {
+	grid := newTestGrid(DefaultBounds, 9, 5, func(i, j int) bool { return i >= 4 && j >= 2 })
+	m, err := ImportanceFromGrid(grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the border runs along the cells of column 3 and row 1, which share
+	// one cell
+	if m.Cells() != 7 {
+		t.Fatalf("expected 7 cells at the border, got %v", m.Cells())
+	}
+	rnd := rand.New(rand.NewSource(3))
+	for k := 0; k < 1000; k++ {
+		cell, weight := m.Pick(rnd.Float64())
+		if weight != 1 {
+			t.Fatalf("expected uniform weights, got %v", weight)
+		}
+		b := m.Cell(cell)
+		if !grid.IsAtBorderFloat64((b.XMin+b.XMax)/2, (b.YMin+b.YMax)/2) {
+			t.Fatalf("expected cell %v to be at the border", b)
+		}
+	}
+}
+
+func TestImportanceMapAdapt(t *testing.T) {
+	m, err := NewImportanceMap(DefaultBounds, 4, 1, []float64{1, 1, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < 10; k++ {
+		m.Observe(0, 10)
+		m.Observe(1, 30)
+	}
+	m.Adapt(0.5)
+
+	// the mean is 20, unobserved cells get the mean
+	expected := []float64{15, 25, 20, 0}
+	for k, w := range expected {
+		if m.weights[k] != w {
+			t.Fatalf("cell %v: expected weight %v, got %v", k, w, m.weights[k])
+		}
+	}
+	if _, weight := m.Pick(0); weight != 60.0/(3*15) {
+		t.Fatalf("expected weight %v, got %v", 60.0/(3*15), weight)
+	}
+}
@@ -0,0 +1,139 @@
+package optimizations
+
+import (
+	"math"
+	"math/big"
+	"moritz/go-fractals/src/complexbig"
+)
+
+// The checks in this file are shortcuts for skipping points that are known
+// to be in the set. The Float64 versions are for samplers, which only need
+// the checks to be exact away from the boundaries of the components.
+
+// IsInMainCardiod checks whether z is in the main cardioid with the standard
+// test q * (q + (x - 1/4)) <= y^2 / 4
+func IsInMainCardiod(z *complexbig.ComplexBig) bool {
+	// q = (x - 1/4)^2 + y^2
+	xShifted := new(big.Float).Sub(z.R, big.NewFloat(0.25))
+	ySquared := new(big.Float).Mul(z.I, z.I)
+	q := new(big.Float).Mul(xShifted, xShifted)
+	q.Add(q, ySquared)
+
+	// leftSide = q * (q + (x - 1/4))
+	leftSide := new(big.Float).Add(q, xShifted)
+	leftSide.Mul(leftSide, q)
+
+	// rightSide = y^2 / 4
+	rightSide := new(big.Float).Quo(ySquared, big.NewFloat(4))
+
+	// leftSide <= rightSide
+	return leftSide.Cmp(rightSide) <= 0
+
+}
+
+// IsInMainCardiodFloat64 is IsInMainCardiod for the point x+y*i in float64
+// precision
+func IsInMainCardiodFloat64(x, y float64) bool {
+	// q = (x - 1/4)^2 + y^2
+	xShifted := x - 0.25
+	q := xShifted*xShifted + y*y
+
+	// q * (q + (x - 1/4)) <= y^2 / 4
+	return q*(q+xShifted) <= y*y/4
+}
+
+// IsInPeriod2Bulb checks whether z is in the disc of radius 1/4 around -1,
+// which is the component of period 2
+func IsInPeriod2Bulb(z *complexbig.ComplexBig) bool {
+	return withinDisc(z, complex(-1, 0), 0.25)
+}
+
+// IsInPeriod2BulbFloat64 is IsInPeriod2Bulb for the point x+y*i in float64
+// precision
+func IsInPeriod2BulbFloat64(x, y float64) bool {
+	// (x + 1)^2 + y^2 <= 1/16
+	return (x+1)*(x+1)+y*y <= 1.0/16
+}
+
+// Disc is a disc that lies within a hyperbolic component of the set
+type Disc struct {
+	// Center is the nucleus of the component, the point whose critical
+	// orbit has the period of the component
+	Center complex128
+	Radius float64
+	Period int
+}
+
+// KnownComponents are discs within some of the largest components of period
+// 3 to 8. Only components in the upper half plane are listed, the set is
+// symmetric. The radii are 90% of the largest radius at which the attracting
+// cycle still has a multiplier below 0.98 everywhere on the circle.
+var KnownComponents = []Disc{
+	// the bulbs attached to the main cardioid at angles 1/3, 1/4, 1/5 and 2/5
+	{Center: complex(-0.122561166877, 0.744861766620), Radius: 0.081, Period: 3},
+	{Center: complex(0.282271390767, 0.530060617579), Radius: 0.037, Period: 4},
+	{Center: complex(0.379513588016, 0.334932305597), Radius: 0.02, Period: 5},
+	{Center: complex(-0.504340175446, 0.562765761453), Radius: 0.033, Period: 5},
+	// the bulbs of period 4 and 8 on the real axis left of the period 2 bulb
+	{Center: complex(-1.310702641337, 0), Radius: 0.05, Period: 4},
+	{Center: complex(-1.381547484432, 0), Radius: 0.011, Period: 8},
+	// the cardioids of the largest minibrots of period 3 and 4
+	{Center: complex(-1.754877666247, 0), Radius: 0.0043, Period: 3},
+	{Center: complex(-0.156520166834, 1.032247108923), Radius: 0.0019, Period: 4},
+}
+
+// IsInKnownComponent checks whether z is in one of the discs or their mirror
+// images in the lower half plane
+func IsInKnownComponent(z *complexbig.ComplexBig, components []Disc) bool {
+	mirrored := &complexbig.ComplexBig{R: z.R, I: new(big.Float).Abs(z.I)}
+
+	for _, disc := range components {
+		if withinDisc(mirrored, disc.Center, disc.Radius) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInKnownComponentFloat64 is IsInKnownComponent for the point x+y*i in
+// float64 precision
+func IsInKnownComponentFloat64(x, y float64, components []Disc) bool {
+	y = math.Abs(y)
+
+	for _, disc := range components {
+		dx := x - real(disc.Center)
+		dy := y - imag(disc.Center)
+		if dx*dx+dy*dy <= disc.Radius*disc.Radius {
+			return true
+		}
+	}
+	return false
+}
+
+// withinDisc checks whether the distance of z from center is at most radius
+func withinDisc(z *complexbig.ComplexBig, center complex128, radius float64) bool {
+	dx := new(big.Float).Sub(z.R, big.NewFloat(real(center)))
+	dy := new(big.Float).Sub(z.I, big.NewFloat(imag(center)))
+
+	// dx^2 + dy^2 <= radius^2
+	distanceSquared := new(big.Float).Mul(dx, dx)
+	distanceSquared.Add(distanceSquared, dy.Mul(dy, dy))
+	return distanceSquared.Cmp(big.NewFloat(radius*radius)) <= 0
+}
+
+// IsKnownInterior combines the checks for the main cardioid, the period 2
+// bulb and the given components, e.g. KnownComponents. components may be
+// nil to only use the exact checks.
+func IsKnownInterior(z *complexbig.ComplexBig, components []Disc) bool {
+	return IsInMainCardiod(z) ||
+		IsInPeriod2Bulb(z) ||
+		IsInKnownComponent(z, components)
+}
+
+// IsKnownInteriorFloat64 is IsKnownInterior for the point x+y*i in float64
+// precision
+func IsKnownInteriorFloat64(x, y float64, components []Disc) bool {
+	return IsInMainCardiodFloat64(x, y) ||
+		IsInPeriod2BulbFloat64(x, y) ||
+		IsInKnownComponentFloat64(x, y, components)
+}
@@ -0,0 +1,198 @@
+package optimizations
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/core"
+	"testing"
+)
+
+// multiplier returns the absolute multiplier of the attracting cycle of c
+// with the given period, or 2 if there is none
+func multiplier(c complex128, period int) float64 {
+	z := complex(0, 0)
+	for i := 0; i < 20000; i++ {
+		z = z*z + c
+		if cmplx.Abs(z) > 2 {
+			return 2
+		}
+	}
+	z0 := z
+	l := complex(1, 0)
+	for i := 0; i < period; i++ {
+		l *= 2 * z
+		z = z*z + c
+	}
+	if cmplx.Abs(z-z0) > 1e-6 {
+		return 2
+	}
+	return cmplx.Abs(l)
+}
+
+func TestIsInMainCardiod(t *testing.T) {
+	// the points of the cardioid are c = l/2 - l^2/4 with |l| < 1, close to
+	// the cusp at l = 1 this map is not one to one
+	for k := 0; k < 1000; k++ {
+		angle := 0.1 + (2*math.Pi-0.2)*float64(k)/1000
+		inside := cmplx.Rect(0.999, angle)
+		outside := cmplx.Rect(1.001, angle)
+
+		if c := inside/2 - inside*inside/4; !IsInMainCardiod(bigPoint(c)) {
+			t.Fatalf("%v: expected a point in the main cardioid", c)
+		}
+		if c := outside/2 - outside*outside/4; IsInMainCardiod(bigPoint(c)) {
+			t.Fatalf("%v: expected a point outside of the main cardioid", c)
+		}
+	}
+}
+
+func TestIsInPeriod2Bulb(t *testing.T) {
+	for k := 0; k < 1000; k++ {
+		angle := 2 * math.Pi * float64(k) / 1000
+		if c := -1 + cmplx.Rect(0.999, angle)/4; !IsInPeriod2Bulb(bigPoint(c)) {
+			t.Fatalf("%v: expected a point in the period 2 bulb", c)
+		}
+		if c := -1 + cmplx.Rect(1.001, angle)/4; IsInPeriod2Bulb(bigPoint(c)) {
+			t.Fatalf("%v: expected a point outside of the period 2 bulb", c)
+		}
+	}
+}
+
+func TestKnownComponents(t *testing.T) {
+	for _, disc := range KnownComponents {
+		if m := multiplier(disc.Center, disc.Period); m > 1e-6 {
+			t.Fatalf("%v: expected a superattracting cycle of period %v, got multiplier %v", disc.Center, disc.Period, m)
+		}
+
+		for k := 0; k < 64; k++ {
+			direction := cmplx.Rect(disc.Radius, 2*math.Pi*float64(k)/64)
+			inside := disc.Center + 0.999*direction
+			outside := disc.Center + 1.001*direction
+
+			// the disc lies within the component
+			if m := multiplier(inside, disc.Period); m >= 1 {
+				t.Fatalf("%v: expected an attracting cycle of period %v, got multiplier %v", inside, disc.Period, m)
+			}
+			for _, c := range []complex128{inside, cmplx.Conj(inside)} {
+				if !IsInKnownComponent(bigPoint(c), KnownComponents) {
+					t.Fatalf("%v: expected a point in a known component", c)
+				}
+			}
+			for _, c := range []complex128{outside, cmplx.Conj(outside)} {
+				if IsInKnownComponent(bigPoint(c), KnownComponents) {
+					t.Fatalf("%v: expected a point outside of the known components", c)
+				}
+			}
+		}
+	}
+}
+
+func TestIsKnownInterior(t *testing.T) {
+	if !IsKnownInterior(bigPoint(0), nil) || !IsKnownInterior(bigPoint(-1), nil) {
+		t.Fatalf("expected the centers of the main cardioid and the period 2 bulb to be known")
+	}
+	bulb := KnownComponents[0].Center
+	if IsKnownInterior(bigPoint(bulb), nil) || !IsKnownInterior(bigPoint(bulb), KnownComponents) {
+		t.Fatalf("expected %v to only be known with the table of components", bulb)
+	}
+
+	// no point that is known to be in the set escapes
+	rnd := rand.New(rand.NewSource(1))
+	known := 0
+	for k := 0; k < 100000; k++ {
+		c := complex(rnd.Float64()*2.5-2, rnd.Float64()*1.25)
+		if !IsKnownInterior(bigPoint(c), KnownComponents) {
+			continue
+		}
+		known++
+		if _, inSet := core.IterateFloat64(c, 1000); !inSet {
+			t.Fatalf("%v: expected a known interior point to not escape", c)
+		}
+	}
+	if known == 0 {
+		t.Fatalf("expected some random points to be known interior points")
+	}
+}
+
+// randomBig returns a random point in [-2, 0.5] x [-1.25, 1.25] with 200 bits
+func randomBig(rnd *rand.Rand) *complexbig.ComplexBig {
+	ctx := complexbig.NewContext(200)
+	r := ctx.NewFloat(rnd.Float64()*2.5 - 2)
+	i := ctx.NewFloat(rnd.Float64()*2.5 - 1.25)
+	// bits beyond float64 precision
+	r.Add(r, ctx.NewFloat(rnd.Float64()*1e-17))
+	i.Add(i, ctx.NewFloat(rnd.Float64()*1e-17))
+	return &complexbig.ComplexBig{R: r, I: i}
+}
+
+// nearBoundary checks whether x+y*i is so close to the boundary of one of the
+// checked components that float64 rounding may decide the checks
+func nearBoundary(x, y float64) bool {
+	const margin = 1e-9
+
+	xShifted := x - 0.25
+	q := xShifted*xShifted + y*y
+	if math.Abs(q*(q+xShifted)-y*y/4) < margin {
+		return true
+	}
+	if math.Abs((x+1)*(x+1)+y*y-1.0/16) < margin {
+		return true
+	}
+	for _, disc := range KnownComponents {
+		dx := x - real(disc.Center)
+		dy := math.Abs(y) - imag(disc.Center)
+		if math.Abs(dx*dx+dy*dy-disc.Radius*disc.Radius) < margin {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFloat64ChecksMatchBig(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	checked := 0
+
+	for k := 0; k < 100000; k++ {
+		z := randomBig(rnd)
+		x, _ := z.R.Float64()
+		y, _ := z.I.Float64()
+		if nearBoundary(x, y) {
+			continue
+		}
+		checked++
+
+		if IsInMainCardiodFloat64(x, y) != IsInMainCardiod(z) {
+			t.Fatalf("%v: expected %v for the main cardioid", z, IsInMainCardiod(z))
+		}
+		if IsInPeriod2BulbFloat64(x, y) != IsInPeriod2Bulb(z) {
+			t.Fatalf("%v: expected %v for the period 2 bulb", z, IsInPeriod2Bulb(z))
+		}
+		if IsInKnownComponentFloat64(x, y, KnownComponents) != IsInKnownComponent(z, KnownComponents) {
+			t.Fatalf("%v: expected %v for the known components", z, IsInKnownComponent(z, KnownComponents))
+		}
+		if IsKnownInteriorFloat64(x, y, KnownComponents) != IsKnownInterior(z, KnownComponents) {
+			t.Fatalf("%v: expected %v for the known interior", z, IsKnownInterior(z, KnownComponents))
+		}
+	}
+	if checked < 99000 {
+		t.Fatalf("expected most points to be away from the boundaries, only %v were", checked)
+	}
+}
+
+func BenchmarkIsKnownInterior(b *testing.B) {
+	z := randomBig(rand.New(rand.NewSource(1)))
+	for i := 0; i < b.N; i++ {
+		IsKnownInterior(z, KnownComponents)
+	}
+}
+
+func BenchmarkIsKnownInteriorFloat64(b *testing.B) {
+	z := randomBig(rand.New(rand.NewSource(1)))
+	for i := 0; i < b.N; i++ {
+		x, _ := z.R.Float64()
+		y, _ := z.I.Float64()
+		IsKnownInteriorFloat64(x, y, KnownComponents)
+	}
+}
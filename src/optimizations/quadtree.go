@@ -0,0 +1,247 @@
+package optimizations
+
+import (
+	"math"
+	"moritz/go-fractals/src/complexbig"
+	"moritz/go-fractals/src/core"
+	"sync"
+)
+
+// Quadtree is an adaptive alternative to Grid. Cells are only subdivided
+// where their corners or center disagree on set membership, so large areas
+// inside and outside of the set are covered by few cells while the border
+// is resolved with cells of the maximum depth.
+// Positions are measured in units of the smallest cells, the lattice, which
+// has 2^maxDepth cells along each axis.
+type Quadtree[T any] struct {
+	root     quadNode
+	bounds   Bounds
+	maxDepth int
+	dilation int
+	arith    core.Arithmetic[T]
+}
+
+type quadNode struct {
+	// children are ordered lower left, lower right, upper left, upper right
+	// and nil for leaves
+	children *[4]quadNode
+	// border is set for leaves of the maximum depth whose corners disagree
+	border bool
+}
+
+// NewQuadtree creates a quadtree within bounds. Cells are subdivided up to
+// minDepth unconditionally, so that features smaller than the root cell are
+// not missed, and up to maxDepth if their corners or center disagree on
+// whether they are in the set. The points are iterated with arith.
+func NewQuadtree[T any](arith core.Arithmetic[T], bounds Bounds, minDepth, maxDepth, maxIt, maxThreads int) *Quadtree[T] {
+	inSet := func(x, y float64) bool {
+		_, inSet := core.IterateWith(arith, arith.FromFloat64(x, y), maxIt)
+		return inSet
+	}
+	return newQuadtree(arith, bounds, minDepth, maxDepth, maxThreads, inSet)
+}
+
+func newQuadtree[T any](arith core.Arithmetic[T], bounds Bounds, minDepth, maxDepth, maxThreads int, inSet func(x, y float64) bool) *Quadtree[T] {
+	if maxDepth < 1 || maxDepth > 30 || minDepth > maxDepth {
+		panic("the depths of a quadtree have to satisfy minDepth <= maxDepth <= 30")
+	}
+	if !(bounds.Width() > 0 && bounds.Height() > 0) {
+		panic("the bounds of a quadtree must not be empty")
+	}
+
+	tree := &Quadtree[T]{bounds: bounds, maxDepth: maxDepth, arith: arith}
+	b := &quadBuilder{
+		n:        1 << maxDepth,
+		bounds:   bounds,
+		minDepth: minDepth,
+		maxDepth: maxDepth,
+		inSet:    inSet,
+		cache:    make(map[uint64]bool),
+		guard:    make(chan struct{}, maxThreads),
+	}
+
+	corners := [4]bool{b.at(0, 0), b.at(b.n, 0), b.at(0, b.n), b.at(b.n, b.n)}
+	b.build(&tree.root, 0, 0, 0, corners)
+	b.wg.Wait()
+	return tree
+}
+
+// quadBuilder evaluates the lattice points that are needed to build a tree
+type quadBuilder struct {
+	n                  int
+	bounds             Bounds
+	minDepth, maxDepth int
+	inSet              func(x, y float64) bool
+
+	// cache holds the points that were already evaluated, corners are
+	// shared by up to 4 cells
+	mu    sync.Mutex
+	cache map[uint64]bool
+	guard chan struct{}
+	wg    sync.WaitGroup
+}
+
+// parallelDepth is the depth up to which subtrees are built concurrently
+const parallelDepth = 3
+
+// at returns whether the lattice point ix, iy is in the set
+func (b *quadBuilder) at(ix, iy int) bool {
+	key := uint64(ix)<<32 | uint64(iy)
+	b.mu.Lock()
+	inSet, ok := b.cache[key]
+	b.mu.Unlock()
+	if ok {
+		return inSet
+	}
+
+	b.guard <- struct{}{}
+	x := b.bounds.XMin + float64(ix)/float64(b.n)*b.bounds.Width()
+	y := b.bounds.YMin + float64(iy)/float64(b.n)*b.bounds.Height()
+	inSet = b.inSet(x, y)
+	<-b.guard
+
+	b.mu.Lock()
+	b.cache[key] = inSet
+	b.mu.Unlock()
+	return inSet
+}
+
+// build builds the node of the cell at depth whose lower left corner is the
+// lattice point ix, iy. corners are ordered like the children.
+func (b *quadBuilder) build(node *quadNode, depth, ix, iy int, corners [4]bool) {
+	disagree := corners[0] != corners[1] || corners[0] != corners[2] || corners[0] != corners[3]
+	if depth == b.maxDepth {
+		node.border = disagree
+		return
+	}
+
+	h := (b.n >> depth) / 2
+	center := b.at(ix+h, iy+h)
+	if !disagree && center == corners[0] && depth >= b.minDepth {
+		return
+	}
+
+	bottom := b.at(ix+h, iy)
+	left := b.at(ix, iy+h)
+	right := b.at(ix+2*h, iy+h)
+	top := b.at(ix+h, iy+2*h)
+
+	node.children = &[4]quadNode{}
+	childCorners := [4][4]bool{
+		{corners[0], bottom, left, center},
+		{bottom, corners[1], center, right},
+		{left, center, corners[2], top},
+		{center, right, top, corners[3]},
+	}
+	for q := 0; q < 4; q++ {
+		child := &node.children[q]
+		cx, cy := ix+(q&1)*h, iy+(q>>1)*h
+		if depth < parallelDepth {
+			b.wg.Add(1)
+			go func(q int) {
+				defer b.wg.Done()
+				b.build(child, depth+1, cx, cy, childCorners[q])
+			}(q)
+			continue
+		}
+		b.build(child, depth+1, cx, cy, childCorners[q])
+	}
+}
+
+// Bounds returns the rectangle that is covered by the tree
+func (tree *Quadtree[T]) Bounds() Bounds {
+	return tree.bounds
+}
+
+// SetDilation makes IsAtBorder also accept points that are at most cells
+// cells of the maximum depth away from a border cell along each axis
+func (tree *Quadtree[T]) SetDilation(cells int) {
+	tree.dilation = cells
+}
+
+// IsAtBorder checks whether z is in a cell of the maximum depth whose corners
+// disagree on set membership, or near one, see SetDilation. z is compared
+// with the lattice in full precision. Points outside of the tree are never at
+// the border.
+func (tree *Quadtree[T]) IsAtBorder(z *complexbig.ComplexBig) bool {
+	n := 1 << tree.maxDepth
+	ix, okX := cellIndex(z.R, n+1, func(k int) float64 {
+		return tree.bounds.XMin + float64(k)/float64(n)*tree.bounds.Width()
+	})
+	iy, okY := cellIndex(z.I, n+1, func(k int) float64 {
+		return tree.bounds.YMin + float64(k)/float64(n)*tree.bounds.Height()
+	})
+	return okX && okY && tree.borderNear(ix, iy)
+}
+
+// IsAtBorderFloat64 is IsAtBorder for the point zR+zI*i in float64 precision
+func (tree *Quadtree[T]) IsAtBorderFloat64(zR, zI float64) bool {
+	n := 1 << tree.maxDepth
+	x := (zR - tree.bounds.XMin) / tree.bounds.Width() * float64(n)
+	y := (zI - tree.bounds.YMin) / tree.bounds.Height() * float64(n)
+
+	// the negated comparisons also reject NaN
+	if !(x >= 0 && x <= float64(n) && y >= 0 && y <= float64(n)) {
+		return false
+	}
+	// points on the upper edges belong to the last cell
+	ix := int(math.Min(x, float64(n-1)))
+	iy := int(math.Min(y, float64(n-1)))
+	return tree.borderNear(ix, iy)
+}
+
+// borderNear checks whether one of the lattice cells around ix, iy is a
+// border cell, see SetDilation
+func (tree *Quadtree[T]) borderNear(ix, iy int) bool {
+	d := tree.dilation
+	for dx := -d; dx <= d; dx++ {
+		for dy := -d; dy <= d; dy++ {
+			if tree.borderAt(ix+dx, iy+dy) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// borderAt looks up the leaf that contains the lattice cell ix, iy
+func (tree *Quadtree[T]) borderAt(ix, iy int) bool {
+	n := 1 << tree.maxDepth
+	if ix < 0 || iy < 0 || ix >= n || iy >= n {
+		return false
+	}
+
+	node := &tree.root
+	size := n
+	for node.children != nil {
+		size /= 2
+		q := 0
+		if ix >= size {
+			q |= 1
+			ix -= size
+		}
+		if iy >= size {
+			q |= 2
+			iy -= size
+		}
+		node = &node.children[q]
+	}
+	return node.border
+}
+
+// Leaves returns the number of cells of the tree, a uniform grid of the same
+// resolution has 4^maxDepth cells
+func (tree *Quadtree[T]) Leaves() int {
+	return countLeaves(&tree.root)
+}
+
+func countLeaves(node *quadNode) int {
+	if node.children == nil {
+		return 1
+	}
+	leaves := 0
+	for q := range node.children {
+		leaves += countLeaves(&node.children[q])
+	}
+	return leaves
+}
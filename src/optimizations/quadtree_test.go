@@ -0,0 +1,152 @@
+package optimizations
+
+import (
+	"math"
+	"math/rand"
+	"moritz/go-fractals/src/core"
+	"moritz/go-fractals/src/fill"
+	"testing"
+)
+
+// inDisc is the membership of a disc, which has no features that are
+// smaller than the cells of the test trees
+func inDisc(x, y float64) bool {
+	return (x-0.1)*(x-0.1)+(y-0.05)*(y-0.05) < 0.49
+}
+
+func TestQuadtreeMatchesGrid(t *testing.T) {
+	bounds := Bounds{XMin: -1, XMax: 1, YMin: -1, YMax: 1}
+	tree := newQuadtree[complex128](core.Float64Arithmetic{}, bounds, 3, 7, 4, inDisc)
+
+	// a uniform grid with a point at every lattice point of the tree
+	grid := newTestGrid(bounds, 129, 129, func(i, j int) bool {
+		return inDisc(bounds.XMin+float64(i)/128*bounds.Width(), bounds.YMin+float64(j)/128*bounds.Height())
+	})
+
+	rnd := rand.New(rand.NewSource(1))
+	for k := 0; k < 20000; k++ {
+		z := complex(rnd.Float64()*2-1, rnd.Float64()*2-1)
+		if tree.IsAtBorder(bigPoint(z)) != grid.IsAtBorder(bigPoint(z)) {
+			t.Fatalf("%v: expected %v, got %v", z, grid.IsAtBorder(bigPoint(z)), tree.IsAtBorder(bigPoint(z)))
+		}
+	}
+
+	// only the cells along the circle are subdivided to the maximum depth
+	if tree.Leaves() > 128*128/4 {
+		t.Fatalf("expected far fewer leaves than %v, got %v", 128*128, tree.Leaves())
+	}
+}
+
+func TestQuadtreeDilation(t *testing.T) {
+	bounds := Bounds{XMin: -1, XMax: 1, YMin: -1, YMax: 1}
+	tree := newQuadtree[complex128](core.Float64Arithmetic{}, bounds, 2, 6, 4, inDisc)
+	cell := 2.0 / 64
+
+	// a point on the circle and points 1.5 and 3.5 cells further outside
+	onBorder := complex(0.8, 0.05)
+	near := onBorder + complex(1.5*cell, 0)
+	far := onBorder + complex(3.5*cell, 0)
+
+	if !tree.IsAtBorder(bigPoint(onBorder)) || tree.IsAtBorder(bigPoint(near)) || tree.IsAtBorder(bigPoint(far)) {
+		t.Fatalf("expected only %v to be at the border", onBorder)
+	}
+	tree.SetDilation(2)
+	if !tree.IsAtBorder(bigPoint(onBorder)) || !tree.IsAtBorder(bigPoint(near)) || tree.IsAtBorder(bigPoint(far)) {
+		t.Fatalf("expected %v and %v to be at the dilated border", onBorder, near)
+	}
+	// the interior of the disc stays outside of the dilated border
+	if tree.IsAtBorder(bigPoint(complex(0.1, 0.05))) {
+		t.Fatalf("expected the center of the disc to not be at the border")
+	}
+}
+
+func TestQuadtreeOutsideOfBounds(t *testing.T) {
+	bounds := Bounds{XMin: 0, XMax: 1, YMin: 0, YMax: 1}
+	// every cell is at the border
+	tree := newQuadtree[complex128](core.Float64Arithmetic{}, bounds, 4, 4, 4, func(x, y float64) bool {
+		return int(x*16+y*16+0.5)%2 == 0
+	})
+	tree.SetDilation(1)
+
+	if !tree.IsAtBorder(bigPoint(complex(0, 0))) || !tree.IsAtBorder(bigPoint(complex(1, 1))) {
+		t.Fatalf("expected the corners to be inside of the tree")
+	}
+	outside := []complex128{
+		complex(-0.001, 0.5), complex(1.001, 0.5), complex(0.5, -0.001), complex(0.5, 1.001),
+		complex(math.Inf(1), 0), complex(0.5, math.Inf(-1)),
+	}
+	for _, z := range outside {
+		if tree.IsAtBorder(bigPoint(z)) {
+			t.Fatalf("%v: expected a point outside of the tree to not be at the border", z)
+		}
+	}
+}
+
+func TestQuadtreeOfMandelbrotSet(t *testing.T) {
+	tree := NewQuadtree[complex128](core.Float64Arithmetic{}, DefaultBounds, 4, 8, 100, 4)
+
+	for _, z := range []complex128{0, complex(-1, 0.05), complex(-1.9, 0.9), complex(1.5, -0.5)} {
+		if tree.IsAtBorder(bigPoint(z)) {
+			t.Fatalf("%v: expected a point far from the border", z)
+		}
+	}
+	// the cusp of the main cardioid
+	if !tree.IsAtBorder(bigPoint(complex(0.25, 0))) {
+		t.Fatalf("expected the cusp to be at the border")
+	}
+
+	// the tree only misses thin filaments that a grid of the same
+	// resolution resolves
+	grid := NewGrid[complex128](core.Float64Arithmetic{}, DefaultBounds, 257, 257, 100, 4, fill.BruteForce)
+	rnd := rand.New(rand.NewSource(1))
+	missed := 0
+	for k := 0; k < 10000; k++ {
+		z := complex(rnd.Float64()*4-2, rnd.Float64()*2-1)
+		if tree.IsAtBorder(bigPoint(z)) && !grid.IsAtBorder(bigPoint(z)) {
+			t.Fatalf("%v: expected a border cell of the tree to be a border cell of the grid", z)
+		}
+		if grid.IsAtBorder(bigPoint(z)) && !tree.IsAtBorder(bigPoint(z)) {
+			missed++
+		}
+	}
+	if missed > 100 {
+		t.Fatalf("expected the tree to miss at most 1%% of the points, missed %v", missed)
+	}
+
+	var borderMap BorderMap = tree
+	if borderMap.Bounds() != DefaultBounds {
+		t.Fatalf("expected %v, got %v", DefaultBounds, borderMap.Bounds())
+	}
+}
+
+func BenchmarkQuadtreeIsAtBorder(b *testing.B) {
+	bounds := Bounds{XMin: -1, XMax: 1, YMin: -1, YMax: 1}
+	tree := newQuadtree[complex128](core.Float64Arithmetic{}, bounds, 3, 10, 4, inDisc)
+	z := bigPoint(complex(0.8, 0.05))
+	for i := 0; i < b.N; i++ {
+		tree.IsAtBorder(z)
+	}
+}
+
+func TestQuadtreeIsAtBorderFloat64MatchesBig(t *testing.T) {
+	tree := NewQuadtree[complex128](core.Float64Arithmetic{}, DefaultBounds, 4, 8, 100, 4)
+	tree.SetDilation(1)
+	// the lattice has 256 cells along each axis
+	cellX, cellY := DefaultBounds.Width()/256, DefaultBounds.Height()/256
+	rnd := rand.New(rand.NewSource(3))
+
+	for k := 0; k < 10000; k++ {
+		z := randomBig(rnd)
+		x, _ := z.R.Float64()
+		y, _ := z.I.Float64()
+
+		// skip points next to the lines between cells
+		u, v := (x-DefaultBounds.XMin)/cellX, (y-DefaultBounds.YMin)/cellY
+		if math.Abs(u-math.Round(u)) < 1e-6 || math.Abs(v-math.Round(v)) < 1e-6 {
+			continue
+		}
+		if tree.IsAtBorderFloat64(x, y) != tree.IsAtBorder(z) {
+			t.Fatalf("%v: expected %v", z, tree.IsAtBorder(z))
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package metrics provides counters, gauges and rate meters for the
+// progress output of long running computations. Counters and gauges are
+// lock-free, so workers can update them on every cycle.
+package metrics
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is an int64 that only grows, the zero value is ready to use
+type Counter struct {
+	v int64
+}
+
+// NewCounter creates a counter that starts at 0
+func NewCounter() *Counter {
+	return &Counter{}
+}
+
+// Add adds delta to the counter
+func (c *Counter) Add(delta int64) {
+	atomic.AddInt64(&c.v, delta)
+}
+
+// Inc adds 1 to the counter
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Value returns the current value of the counter
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.v)
+}
+
+// Gauge is a float64 that can be set to any value, the zero value is 0
+type Gauge struct {
+	bits uint64
+}
+
+// NewGauge creates a gauge that starts at 0
+func NewGauge() *Gauge {
+	return &Gauge{}
+}
+
+// Set sets the value of the gauge
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+// Value returns the current value of the gauge
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+// Rate measures how fast a counter grows as moving average over a window
+// of time. It keeps samples of the counter, which are taken by Sample, e.g.
+// whenever the rate is printed.
+type Rate struct {
+	counter *Counter
+	window  time.Duration
+
+	mu      sync.Mutex
+	samples []rateSample
+}
+
+type rateSample struct {
+	t time.Time
+	v int64
+}
+
+// NewRate creates a meter for the growth of counter per second over the
+// last window
+func NewRate(counter *Counter, window time.Duration) *Rate {
+	return &Rate{counter: counter, window: window}
+}
+
+// Sample records the value of the counter at now. Samples older than the
+// window are dropped, except for the newest of them, so that the rate
+// covers the whole window.
+func (r *Rate) Sample(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, rateSample{t: now, v: r.counter.Value()})
+
+	start := now.Add(-r.window)
+	drop := 0
+	for drop+1 < len(r.samples) && !r.samples[drop+1].t.After(start) {
+		drop++
+	}
+	r.samples = r.samples[drop:]
+}
+
+// PerSecond returns the average growth of the counter per second between
+// the oldest and the newest sample, it is 0 until two samples were taken
+func (r *Rate) PerSecond() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.samples) < 2 {
+		return 0
+	}
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+	seconds := last.t.Sub(first.t).Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(last.v-first.v) / seconds
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 1000; k++ {
+				c.Inc()
+				c.Add(2)
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Value() != 24000 {
+		t.Fatalf("expected 24000, got %v", c.Value())
+	}
+}
+
+func TestGauge(t *testing.T) {
+	g := NewGauge()
+	if g.Value() != 0 {
+		t.Fatalf("expected 0, got %v", g.Value())
+	}
+	g.Set(-1.5)
+	if g.Value() != -1.5 {
+		t.Fatalf("expected -1.5, got %v", g.Value())
+	}
+}
+
+func TestRate(t *testing.T) {
+	c := NewCounter()
+	r := NewRate(c, 10*time.Second)
+	start := time.Unix(0, 0)
+
+	r.Sample(start)
+	if r.PerSecond() != 0 {
+		t.Fatalf("expected 0 for a single sample, got %v", r.PerSecond())
+	}
+
+	// 100 per second for 10 seconds
+	for s := 1; s <= 10; s++ {
+		c.Add(100)
+		r.Sample(start.Add(time.Duration(s) * time.Second))
+	}
+	if r.PerSecond() != 100 {
+		t.Fatalf("expected 100, got %v", r.PerSecond())
+	}
+
+	// 400 per second for 5 seconds, half of the window
+	for s := 11; s <= 15; s++ {
+		c.Add(400)
+		r.Sample(start.Add(time.Duration(s) * time.Second))
+	}
+	if r.PerSecond() != 250 {
+		t.Fatalf("expected 250, got %v", r.PerSecond())
+	}
+
+	// the samples before the window are dropped
+	if len(r.samples) != 11 {
+		t.Fatalf("expected 11 samples, got %v", len(r.samples))
+	}
+}
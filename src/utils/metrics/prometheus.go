@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry collects metrics under names and writes them in the Prometheus
+// text format. Metrics of the same name with different labels form a
+// family, they are written together in the order of their first
+// registration.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+type family struct {
+	name, help, kind string
+	series           []series
+}
+
+type series struct {
+	labels string
+	value  func() float64
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter registers c. labels are pairs of label names and values, e.g.
+// "filter", "border".
+func (r *Registry) Counter(name, help string, c *Counter, labels ...string) {
+	r.register(name, help, "counter", func() float64 { return float64(c.Value()) }, labels)
+}
+
+// Gauge registers g, see Counter for labels
+func (r *Registry) Gauge(name, help string, g *Gauge, labels ...string) {
+	r.register(name, help, "gauge", g.Value, labels)
+}
+
+// GaugeFunc registers a gauge whose value is computed by f whenever the
+// metrics are written, see Counter for labels
+func (r *Registry) GaugeFunc(name, help string, f func() float64, labels ...string) {
+	r.register(name, help, "gauge", f, labels)
+}
+
+func (r *Registry) register(name, help, kind string, value func() float64, labels []string) {
+	if len(labels)%2 != 0 {
+		panic("metrics: labels have to be pairs of names and values")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var f *family
+	for _, existing := range r.families {
+		if existing.name == name {
+			f = existing
+		}
+	}
+	if f == nil {
+		f = &family{name: name, help: help, kind: kind}
+		r.families = append(r.families, f)
+	}
+	if f.kind != kind {
+		panic(fmt.Sprintf("metrics: %v is registered as %v and %v", name, f.kind, kind))
+	}
+	f.series = append(f.series, series{labels: formatLabels(labels), value: value})
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for k := 0; k < len(labels); k += 2 {
+		pairs = append(pairs, labels[k]+`="`+labelEscaper.Replace(labels[k+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+// WriteText writes the current values of all metrics in the Prometheus text
+// exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range r.families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, helpEscaper.Replace(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.kind)
+		for _, s := range f.series {
+			fmt.Fprintf(bw, "%s%s %s\n", f.name, s.labels, formatValue(s.value()))
+		}
+	}
+	return bw.Flush()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case v == math.Trunc(v) && math.Abs(v) < 1e15:
+		// counts are written without exponent
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// ServeHTTP implements http.Handler, it serves the metrics for scraping
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
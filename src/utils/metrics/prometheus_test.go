@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry() (*Registry, *Counter, *Gauge) {
+	r := NewRegistry()
+	c := NewCounter()
+	g := NewGauge()
+	rejected := NewCounter()
+	r.Counter("test_cycles_total", "Number of cycles.", c)
+	r.Counter("test_rejected_total", "Rejected samples\nby filter.", rejected, "filter", "border")
+	r.Gauge("test_max", "Maximum value.", g)
+	r.Counter("test_rejected_total", "", NewCounter(), "filter", `a"b\c`)
+	r.GaugeFunc("test_inf", "Infinity.", func() float64 { return math.Inf(1) })
+	c.Add(4200000)
+	g.Set(1.5)
+	rejected.Inc()
+	return r, c, g
+}
+
+const expectedText = `# HELP test_cycles_total Number of cycles.
+# TYPE test_cycles_total counter
+test_cycles_total 4200000
+# HELP test_rejected_total Rejected samples\nby filter.
+# TYPE test_rejected_total counter
+test_rejected_total{filter="border"} 1
+test_rejected_total{filter="a\"b\\c"} 0
+# HELP test_max Maximum value.
+# TYPE test_max gauge
+test_max 1.5
+# HELP test_inf Infinity.
+# TYPE test_inf gauge
+test_inf +Inf
+`
+
+func TestWriteText(t *testing.T) {
+	r, _, _ := newTestRegistry()
+	var text strings.Builder
+	if err := r.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if text.String() != expectedText {
+		t.Fatalf("expected\n%s\ngot\n%s", expectedText, text.String())
+	}
+}
+
+func TestRegistryKindMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for a gauge with the name of a counter")
+		}
+	}()
+	r := NewRegistry()
+	r.Counter("test_total", "", NewCounter())
+	r.Gauge("test_total", "", NewGauge())
+}
+
+func TestServeHTTP(t *testing.T) {
+	r, c, _ := newTestRegistry()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	get := func() string {
+		resp, err := http.Get(server.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %v", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+			t.Fatalf("expected the Prometheus text format, got %q", ct)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	if body := get(); body != expectedText {
+		t.Fatalf("expected\n%s\ngot\n%s", expectedText, body)
+	}
+	// every scrape reads the current values
+	c.Add(1)
+	if body := get(); !strings.Contains(body, "\ntest_cycles_total 4200001\n") {
+		t.Fatalf("expected the updated counter, got\n%s", body)
+	}
+}